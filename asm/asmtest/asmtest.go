@@ -0,0 +1,360 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package asmtest helps with testing geas contracts using the go test framework.
+//
+// Contracts are compiled and deployed into an in-memory state by [Deploy]. Calls to the
+// contract return a [Result], which has methods for checking the outcome:
+//
+//	func TestCounter(t *testing.T) {
+//	    c := asmtest.Deploy(t, os.DirFS("."), "counter.eas")
+//	    res := c.Call(nil)
+//	    res.ExpectReturn(common.LeftPadBytes([]byte{1}, 32))
+//	    res.ExpectStorage(common.Hash{}, common.BigToHash(big.NewInt(1)))
+//	}
+//
+// When a check fails, the test failure message shows the location in the source code
+// where execution ended.
+//
+// The instructions executed by calls are recorded. Use [Contract.WriteCoverage] to
+// create a coverage report after running the tests.
+package asmtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/coverage"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/holiman/uint256"
+)
+
+// Contract is a compiled contract deployed into a test state.
+// The state is kept across calls.
+type Contract struct {
+	tb    testing.TB
+	code  []byte
+	info  *asm.DebugInfo
+	addr  common.Address
+	state *state.StateDB
+	fsys  fs.FS
+	cov   *coverage.Profile
+}
+
+// Deploy compiles the given file and creates the contract. Compiler warnings are
+// logged. If compilation fails, the test is stopped.
+func Deploy(tb testing.TB, fsys fs.FS, file string) *Contract {
+	tb.Helper()
+	c := asm.New(fsys)
+	contract := deploy(tb, c, c.CompileFile(file))
+	contract.fsys = fsys
+	return contract
+}
+
+// DeployString compiles the given source code and creates the contract.
+// The code cannot use #include.
+func DeployString(tb testing.TB, code string) *Contract {
+	tb.Helper()
+	c := asm.New(nil)
+	return deploy(tb, c, c.CompileString(code))
+}
+
+func deploy(tb testing.TB, c *asm.Compiler, code []byte) *Contract {
+	tb.Helper()
+	for _, err := range c.ErrorsAndWarnings() {
+		tb.Log(err)
+	}
+	if c.Failed() {
+		tb.Fatal("compilation failed")
+	}
+	st, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	st.CreateAccount(evmrun.DefaultAddress)
+	st.SetCode(evmrun.DefaultAddress, code, tracing.CodeChangeUnspecified)
+	info := c.DebugInfo()
+	return &Contract{
+		tb:    tb,
+		code:  code,
+		info:  info,
+		addr:  evmrun.DefaultAddress,
+		state: st,
+		cov:   coverage.New(info),
+	}
+}
+
+// Code returns the bytecode of the contract.
+func (c *Contract) Code() []byte {
+	return c.code
+}
+
+// Address returns the address of the contract.
+func (c *Contract) Address() common.Address {
+	return c.addr
+}
+
+// DebugInfo returns the debug information of the contract.
+func (c *Contract) DebugInfo() *asm.DebugInfo {
+	return c.info
+}
+
+// State returns the state containing the contract.
+func (c *Contract) State() *state.StateDB {
+	return c.state
+}
+
+// Storage returns the value of a storage slot.
+func (c *Contract) Storage(slot common.Hash) common.Hash {
+	return c.state.GetState(c.addr, slot)
+}
+
+// SetStorage sets the value of a storage slot.
+func (c *Contract) SetStorage(slot, value common.Hash) {
+	c.state.SetState(c.addr, slot, value)
+}
+
+// SetBalance sets the balance of an account.
+func (c *Contract) SetBalance(addr common.Address, balance *big.Int) {
+	c.state.SetBalance(addr, uint256.MustFromBig(balance), tracing.BalanceChangeUnspecified)
+}
+
+// WriteCoverage writes the coverage of all calls so far in LCOV format.
+func (c *Contract) WriteCoverage(w io.Writer) error {
+	return c.coverageReport().WriteLCOV(w)
+}
+
+// WriteCoverageHTML writes the coverage of all calls so far as an HTML page.
+// The source code is only shown for contracts created by [Deploy].
+func (c *Contract) WriteCoverageHTML(w io.Writer) error {
+	return c.coverageReport().WriteHTML(w, c.readSource)
+}
+
+func (c *Contract) coverageReport() *coverage.Report {
+	r := coverage.NewReport()
+	r.Add(c.cov)
+	return r
+}
+
+func (c *Contract) readSource(file string) []string {
+	if c.fsys == nil {
+		return nil
+	}
+	content, err := fs.ReadFile(c.fsys, file)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(content), "\n")
+}
+
+// Call calls the contract with the given calldata.
+func (c *Contract) Call(input []byte) *Result {
+	c.tb.Helper()
+	return c.CallWith(Call{Input: input})
+}
+
+// Call contains the parameters of a contract call.
+type Call struct {
+	Input    []byte
+	Value    *big.Int       // callvalue, the caller is funded with this amount
+	Caller   common.Address // defaults to evmrun.DefaultCaller
+	GasLimit uint64         // defaults to 30M
+}
+
+// CallWith calls the contract.
+func (c *Contract) CallWith(call Call) *Result {
+	c.tb.Helper()
+	res, err := evmrun.Run(c.code, evmrun.Config{
+		Fork:     c.info.Fork,
+		Input:    call.Input,
+		Value:    call.Value,
+		Caller:   call.Caller,
+		Address:  c.addr,
+		GasLimit: call.GasLimit,
+		State:    c.state,
+		Tracer:   c.cov.Hooks(),
+	})
+	if err != nil {
+		c.tb.Fatal(err)
+	}
+	c.state.Finalise(true)
+	return &Result{
+		ReturnData:   res.ReturnData,
+		Err:          res.Err,
+		GasUsed:      res.GasUsed,
+		RevertReason: res.RevertReason,
+		Logs:         res.Logs,
+		End:          c.info.InstructionAt(int(res.LastPC)),
+		c:            c,
+		lastOp:       res.LastOp,
+	}
+}
+
+// Result is the outcome of a contract call.
+type Result struct {
+	ReturnData   []byte
+	Err          error  // execution error, vm.ErrExecutionReverted for REVERT
+	GasUsed      uint64 // not including intrinsic gas
+	RevertReason string // decoded revert reason
+	Logs         []*types.Log
+
+	// End is the instruction where execution ended. It is nil if execution ended outside
+	// of the program, i.e. by running past the end of the code.
+	End *asm.DebugInstruction
+
+	c      *Contract
+	lastOp vm.OpCode
+}
+
+// Reverted reports whether execution ended with REVERT.
+func (r *Result) Reverted() bool {
+	return errors.Is(r.Err, vm.ErrExecutionReverted)
+}
+
+// ExpectSuccess checks that execution did not fail.
+func (r *Result) ExpectSuccess() {
+	r.c.tb.Helper()
+	if r.Err != nil {
+		r.fail("execution failed: %v", r.errorText())
+	}
+}
+
+// ExpectReturn checks that execution succeeded with the given return data.
+func (r *Result) ExpectReturn(want []byte) {
+	r.c.tb.Helper()
+	switch {
+	case r.Err != nil:
+		r.fail("execution failed: %v", r.errorText())
+	case !bytes.Equal(r.ReturnData, want):
+		r.fail("wrong return data\n  have 0x%x\n  want 0x%x", r.ReturnData, want)
+	}
+}
+
+// ExpectRevert checks that execution ended with REVERT.
+func (r *Result) ExpectRevert() {
+	r.c.tb.Helper()
+	if !r.Reverted() {
+		r.fail("execution did not revert (error: %v)", r.Err)
+	}
+}
+
+// ExpectRevertReason checks that execution reverted with the given reason.
+func (r *Result) ExpectRevertReason(reason string) {
+	r.c.tb.Helper()
+	switch {
+	case !r.Reverted():
+		r.fail("execution did not revert (error: %v)", r.Err)
+	case r.RevertReason != reason:
+		r.fail("wrong revert reason %q, want %q", r.RevertReason, reason)
+	}
+}
+
+// ExpectStorage checks the value of a contract storage slot after the call.
+func (r *Result) ExpectStorage(slot, want common.Hash) {
+	r.c.tb.Helper()
+	if have := r.c.Storage(slot); have != want {
+		r.fail("wrong value in storage slot %v\n  have %v\n  want %v", slot, have, want)
+	}
+}
+
+// ExpectLogCount checks the number of emitted logs.
+func (r *Result) ExpectLogCount(n int) {
+	r.c.tb.Helper()
+	if len(r.Logs) != n {
+		r.fail("%d logs emitted, want %d", len(r.Logs), n)
+	}
+}
+
+// ExpectLog checks the log at the given index.
+func (r *Result) ExpectLog(index int, topics []common.Hash, data []byte) {
+	r.c.tb.Helper()
+	if index >= len(r.Logs) {
+		r.fail("log %d not emitted (%d logs total)", index, len(r.Logs))
+		return
+	}
+	l := r.Logs[index]
+	if !slices.Equal(l.Topics, topics) {
+		r.fail("wrong topics in log %d\n  have %v\n  want %v", index, l.Topics, topics)
+	}
+	if !bytes.Equal(l.Data, data) {
+		r.fail("wrong data in log %d\n  have 0x%x\n  want 0x%x", index, l.Data, data)
+	}
+}
+
+// ExpectGasAtMost checks that the call used no more than the given amount of gas.
+func (r *Result) ExpectGasAtMost(limit uint64) {
+	r.c.tb.Helper()
+	if r.GasUsed > limit {
+		r.fail("gas used %d exceeds %d", r.GasUsed, limit)
+	}
+}
+
+func (r *Result) errorText() string {
+	if r.Reverted() && r.RevertReason != "" {
+		return fmt.Sprintf("%v: %s", r.Err, r.RevertReason)
+	}
+	return r.Err.Error()
+}
+
+// fail reports a test failure. The message is augmented with the location where
+// execution ended.
+func (r *Result) fail(format string, args ...any) {
+	r.c.tb.Helper()
+	r.c.tb.Error(fmt.Sprintf(format, args...) + "\n" + r.Location())
+}
+
+// Location describes the source location where execution ended.
+func (r *Result) Location() string {
+	if r.End == nil {
+		return fmt.Sprintf("execution ended at %v outside of program", r.lastOp)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "execution ended at %s (pc %d)\n", r.End.Op, r.End.PC)
+	fmt.Fprintf(&b, "  %v", r.End.Pos)
+	if l := labelBefore(r.c.info, r.End.PC); l != nil {
+		fmt.Fprintf(&b, " (after label %s)", l.Name)
+	}
+	for _, f := range r.End.Expansion {
+		if f.Macro != "" {
+			fmt.Fprintf(&b, "\n  in macro %%%s called at %v", f.Macro, f.Pos)
+		} else {
+			fmt.Fprintf(&b, "\n  in %s included at %v", f.Include, f.Pos)
+		}
+	}
+	return b.String()
+}
+
+// labelBefore returns the closest non-dotted label at or before pc.
+func labelBefore(info *asm.DebugInfo, pc int) *asm.DebugLabel {
+	var found *asm.DebugLabel
+	for i := range info.Labels {
+		l := &info.Labels[i]
+		if !l.Dotted && l.PC <= pc && (found == nil || l.PC >= found.PC) {
+			found = l
+		}
+	}
+	return found
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asmtest
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// recorder captures test failures.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper()                   {}
+func (r *recorder) Log(args ...any)           {}
+func (r *recorder) Error(args ...any)         { r.errors = append(r.errors, fmt.Sprint(args...)) }
+func (r *recorder) Errorf(f string, a ...any) { r.errors = append(r.errors, fmt.Sprintf(f, a...)) }
+
+var testFS = fstest.MapFS{
+	"main.eas": {Data: []byte(`
+#pragma target "cancun"
+#include "lib.eas"
+
+	push 0
+	calldataload            ; [x]
+	dup1                    ; [x, x]
+	jumpi @store            ; [x]
+	%Fail()
+
+store:
+	push 0                  ; [0, x]
+	sstore                  ; []
+	push 0xaa               ; [data]
+	push 0
+	mstore                  ; []
+	push 0xff               ; [topic]
+	push 32
+	push 0
+	log1                    ; []
+	stop
+`)},
+	"lib.eas": {Data: []byte(`
+#define %Fail() {
+	push 0
+	push 0
+	revert
+}
+`)},
+}
+
+func word(v int64) common.Hash {
+	return common.BigToHash(big.NewInt(v))
+}
+
+func TestContract(t *testing.T) {
+	c := Deploy(t, testFS, "main.eas")
+
+	res := c.Call(word(5).Bytes())
+	res.ExpectSuccess()
+	res.ExpectStorage(word(0), word(5))
+	res.ExpectLogCount(1)
+	res.ExpectLog(0, []common.Hash{word(0xff)}, word(0xaa).Bytes())
+
+	// Logs and storage changes are not carried over to the next call.
+	res = c.Call(nil)
+	res.ExpectRevert()
+	res.ExpectLogCount(0)
+	res.ExpectStorage(word(0), word(5))
+}
+
+func TestFailureLocation(t *testing.T) {
+	var rec recorder
+	c := Deploy(&rec, testFS, "main.eas")
+	c.Call(nil).ExpectReturn(nil)
+	if len(rec.errors) != 1 {
+		t.Fatalf("wrong number of errors: %q", rec.errors)
+	}
+	msg := rec.errors[0]
+	t.Log(msg)
+	for _, want := range []string{
+		"execution failed: execution reverted",
+		"execution ended at REVERT",
+		"lib.eas:5:1",
+		"in macro %Fail called at main.eas:9:2",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message does not contain %q", want)
+		}
+	}
+}
+
+func TestDeployString(t *testing.T) {
+	c := DeployString(t, `
+	push 0
+	sload
+	push 1
+	add
+	dup1
+	push 0
+	sstore
+	push 0
+	mstore
+	push 32
+	push 0
+	return
+	`)
+	c.SetStorage(word(0), word(41))
+	c.Call(nil).ExpectReturn(word(42).Bytes())
+	c.Call(nil).ExpectReturn(word(43).Bytes())
+	if v := c.Storage(word(0)); v != word(43) {
+		t.Errorf("wrong storage value %v", v)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	c := Deploy(t, testFS, "main.eas")
+	c.Call(nil)
+
+	var buf strings.Builder
+	if err := c.WriteCoverage(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lcov := buf.String()
+	for _, want := range []string{
+		"SF:main.eas\n",
+		"DA:5,1\n",  // push 0
+		"DA:12,0\n", // store: push 0
+		"BRDA:8,0,0,1\nBRDA:8,0,1,0\n",
+		"SF:lib.eas\n",
+		"DA:5,1\n", // revert
+	} {
+		if !strings.Contains(lcov, want) {
+			t.Errorf("LCOV output does not contain %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(lcov)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"io/fs"
+
+	"github.com/fjl/geas/internal/loader"
+)
+
+// ParseCache holds parsed source files, allowing them to be reused across compilations.
+//
+// A Compiler is not safe for concurrent use, but a single ParseCache can be shared by any
+// number of compilers running concurrently. This is useful when many programs include the
+// same library files. Files which have changed since they were cached are parsed again,
+// replacing the previous entry.
+type ParseCache struct {
+	c *loader.ParseCache
+}
+
+// NewParseCache creates an empty cache.
+func NewParseCache() *ParseCache {
+	return &ParseCache{c: loader.NewParseCache()}
+}
+
+// Len returns the number of cached files.
+func (pc *ParseCache) Len() int {
+	return pc.c.Len()
+}
+
+// Clear removes all cached files.
+func (pc *ParseCache) Clear() {
+	pc.c.Clear()
+}
+
+// Service compiles programs using a shared ParseCache. Unlike Compiler, it is safe for
+// concurrent use: each compilation runs in a new Compiler, which is returned to the
+// caller for inspecting errors, warnings and debug information.
+type Service struct {
+	cache *ParseCache
+	setup func(*Compiler)
+}
+
+// NewService creates a compilation service. The setup function, if non-nil, is called
+// to configure each new Compiler before it runs. It must not call SetParseCache.
+func NewService(setup func(*Compiler)) *Service {
+	return &Service{cache: NewParseCache(), setup: setup}
+}
+
+// Cache returns the parse cache of the service.
+func (s *Service) Cache() *ParseCache {
+	return s.cache
+}
+
+func (s *Service) newCompiler(fsys fs.FS) *Compiler {
+	c := New(fsys)
+	if s.setup != nil {
+		s.setup(c)
+	}
+	c.SetParseCache(s.cache)
+	return c
+}
+
+// CompileFile compiles the given file of fsys.
+func (s *Service) CompileFile(fsys fs.FS, filename string) (*Compiler, []byte) {
+	c := s.newCompiler(fsys)
+	return c, c.CompileFile(filename)
+}
+
+// CompileSource compiles the given source text. See Compiler.CompileSource.
+func (s *Service) CompileSource(fsys fs.FS, filename string, input []byte) (*Compiler, []byte) {
+	c := s.newCompiler(fsys)
+	return c, c.CompileSource(filename, input)
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+var parseCacheTestFS = fstest.MapFS{
+	"lib.eas": {Data: []byte(`
+#define Double(x) = $x * 2
+#define %Store(slot) {
+    push Double($slot)
+    sstore
+}
+`)},
+	"block.eas": {Data: []byte(`
+start:
+    push @start
+    pop
+`)},
+	"sub.eas": {Data: []byte(`
+#include "lib.eas"
+%Store(1)
+`)},
+	"a.eas": {Data: []byte(`
+#include "lib.eas"
+#include "block.eas"
+#include "block.eas"
+push 1
+%Store(2)
+#bytes assemble("sub.eas")
+`)},
+	"b.eas": {Data: []byte(`
+#include "lib.eas"
+#include "block.eas"
+push 2
+%Store(3)
+`)},
+}
+
+// This test runs many compilations concurrently, with a shared cache.
+// It is most useful when run with the race detector.
+func TestParseCacheConcurrent(t *testing.T) {
+	files := []string{"a.eas", "b.eas"}
+	want := make(map[string][]byte)
+	for _, file := range files {
+		c := New(parseCacheTestFS)
+		want[file] = c.CompileFile(file)
+		if c.Failed() {
+			t.Fatalf("%s: %v", file, c.Errors())
+		}
+	}
+
+	cache := NewParseCache()
+	var wg sync.WaitGroup
+	errc := make(chan error, 16)
+	for i := range 16 {
+		file := files[i%len(files)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := New(parseCacheTestFS)
+			c.SetParseCache(cache)
+			output := c.CompileFile(file)
+			if c.Failed() {
+				errc <- fmt.Errorf("%s: %v", file, c.Errors())
+			} else if !bytes.Equal(output, want[file]) {
+				errc <- fmt.Errorf("%s: wrong output %x, want %x", file, output, want[file])
+			}
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Error(err)
+	}
+	if n := cache.Len(); n != 5 {
+		t.Errorf("wrong cache size %d, want 5", n)
+	}
+}
+
+func TestParseCacheInvalidate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.eas": {Data: []byte(`#include "lib.eas"` + "\npush X\n")},
+		"lib.eas":  {Data: []byte("#define X = 1\n")},
+	}
+	cache := NewParseCache()
+	compile := func() []byte {
+		c := New(fsys)
+		c.SetParseCache(cache)
+		output := c.CompileFile("main.eas")
+		if c.Failed() {
+			t.Fatal(c.Errors())
+		}
+		return output
+	}
+
+	if output := compile(); !bytes.Equal(output, []byte{0x60, 0x01}) {
+		t.Fatalf("wrong output %x", output)
+	}
+	fsys["lib.eas"] = &fstest.MapFile{Data: []byte("#define X = 2\n")}
+	if output := compile(); !bytes.Equal(output, []byte{0x60, 0x02}) {
+		t.Fatalf("wrong output %x after change", output)
+	}
+	if n := cache.Len(); n != 2 {
+		t.Errorf("wrong cache size %d, want 2", n)
+	}
+}
+
+// This checks that changed files replace their previous cache entry.
+func TestParseCacheReplace(t *testing.T) {
+	fsys := fstest.MapFS{}
+	cache := NewParseCache()
+	for i := range 10 {
+		fsys["main.eas"] = &fstest.MapFile{Data: []byte(fmt.Sprintf("push %d\n", i+1))}
+		c := New(fsys)
+		c.SetParseCache(cache)
+		output := c.CompileFile("main.eas")
+		if c.Failed() {
+			t.Fatal(c.Errors())
+		}
+		if want := []byte{0x60, byte(i + 1)}; !bytes.Equal(output, want) {
+			t.Fatalf("wrong output %x, want %x", output, want)
+		}
+		if n := cache.Len(); n != 1 {
+			t.Fatalf("wrong cache size %d after %d compilations, want 1", n, i+1)
+		}
+	}
+}
+
+// This checks that the same file name can be used with different file systems.
+func TestParseCacheFilesystems(t *testing.T) {
+	fs1 := fstest.MapFS{"main.eas": {Data: []byte("push 1\n")}}
+	fs2 := fstest.MapFS{"main.eas": {Data: []byte("push 2\n")}}
+	svc := NewService(nil)
+	for range 2 {
+		for i, fsys := range []fstest.MapFS{fs1, fs2} {
+			c, output := svc.CompileFile(fsys, "main.eas")
+			if c.Failed() {
+				t.Fatal(c.Errors())
+			}
+			if want := []byte{0x60, byte(i + 1)}; !bytes.Equal(output, want) {
+				t.Fatalf("wrong output %x, want %x", output, want)
+			}
+		}
+	}
+	if n := svc.Cache().Len(); n != 1 {
+		t.Errorf("wrong cache size %d, want 1", n)
+	}
+}
+
+// This test uses Service from many goroutines. Run it with the race detector.
+func TestServiceConcurrent(t *testing.T) {
+	svc := NewService(func(c *Compiler) {
+		c.SetStackCheck(true)
+	})
+	want := make(map[string][]byte)
+	for _, file := range []string{"a.eas", "b.eas"} {
+		c, output := svc.CompileFile(parseCacheTestFS, file)
+		if c.Failed() {
+			t.Fatalf("%s: %v", file, c.Errors())
+		}
+		want[file] = output
+	}
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 32)
+	for i := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var (
+				c      *Compiler
+				output []byte
+				file   = "a.eas"
+			)
+			if i%2 == 0 {
+				c, output = svc.CompileFile(parseCacheTestFS, file)
+			} else {
+				file = "b.eas"
+				source := parseCacheTestFS[file].Data
+				c, output = svc.CompileSource(parseCacheTestFS, file, source)
+			}
+			if c.Failed() {
+				errc <- fmt.Errorf("%s: %v", file, c.Errors())
+			} else if !bytes.Equal(output, want[file]) {
+				errc <- fmt.Errorf("%s: wrong output %x, want %x", file, output, want[file])
+			}
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Error(err)
+	}
+}
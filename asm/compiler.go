@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"io/fs"
 	"maps"
+	"math"
 	"math/big"
 	"slices"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/fjl/geas/internal/evm"
 	"github.com/fjl/geas/internal/loader"
 	"github.com/fjl/geas/internal/lzint"
+	"github.com/fjl/geas/internal/set"
 	"github.com/fjl/geas/internal/stackcheck"
 )
 
@@ -38,6 +40,10 @@ import (
 var globalOverrideDoc = &ast.Document{File: "<override>"}
 
 // Compiler turns assembly source into bytecode.
+//
+// A Compiler is not safe for concurrent use. To compile programs concurrently, create a
+// Compiler for each goroutine, or use Service. Compilers can share parsed files through a
+// ParseCache.
 type Compiler struct {
 	macroOverrides map[string]*ast.ExpressionMacroDef
 
@@ -46,6 +52,10 @@ type Compiler struct {
 	errors     *loader.ErrorList
 
 	doStackCheck bool
+	doDeadCode   bool
+	doLayout     bool
+
+	lastProg *compilerProg // for DebugInfo
 }
 
 // NewCompiler creates a compiler.
@@ -70,6 +80,7 @@ func New(fsys fs.FS) *Compiler {
 func (c *Compiler) reset() {
 	c.macroStack = make(map[*ast.InstructionMacroDef]struct{})
 	c.errors.Clear()
+	c.lastProg = nil
 }
 
 // SetFilesystem sets the file system used for resolving #include files.
@@ -78,6 +89,16 @@ func (c *Compiler) SetFilesystem(fsys fs.FS) {
 	c.loader.SetFilesystem(fsys)
 }
 
+// SetParseCache sets the cache of parsed source files.
+// If set to nil (the default), all files are parsed on every compilation.
+func (c *Compiler) SetParseCache(pc *ParseCache) {
+	if pc == nil {
+		c.loader.SetParseCache(nil)
+	} else {
+		c.loader.SetParseCache(pc.c)
+	}
+}
+
 // SetDefaultFork sets the EVM instruction set used by default.
 func (c *Compiler) SetDefaultFork(f string) {
 	c.loader.SetDefaultFork(f)
@@ -98,6 +119,36 @@ func (c *Compiler) SetStackCheck(on bool) {
 	c.doStackCheck = on
 }
 
+// SetDeadCodeElimination enables or disables removal of unreachable code.
+//
+// When enabled, basic blocks which cannot be reached by execution are dropped from the
+// output. A block is considered reachable when control can fall through into it from a
+// reachable block, or when it contains the target of a label reference made by
+// reachable code. Note this means a JUMPDEST which is never referenced by any expression
+// is assumed to be unreachable, so a warning is emitted for every label of removed
+// code. Blocks containing #bytes are always kept.
+func (c *Compiler) SetDeadCodeElimination(on bool) {
+	c.doDeadCode = on
+}
+
+// SetLayoutOptimization enables or disables the code layout optimizer.
+//
+// When enabled, jumps to blocks which contain nothing but another jump are redirected to
+// the final destination, jump targets are moved to directly follow the jump where
+// possible, and jumps to the immediately following instruction are removed. A block is
+// only moved if it starts with a label, ends in a terminal instruction or unconditional
+// jump, and is not entered by fall-through. Blocks are never moved across macro or
+// #include boundaries.
+//
+// Labels within or just after a block must only be used as jump destinations, i.e.
+// 'jump @label' or 'jumpi @label'. If a label is used in any other way, for example in
+// arithmetic like '@.end - @.start', the block stays in place. Note that expressions
+// computing the distance between two labels elsewhere in the program can still change
+// when a block in between is moved.
+func (c *Compiler) SetLayoutOptimization(on bool) {
+	c.doLayout = on
+}
+
 // SetGlobal sets the value of a global expression macro.
 // Note the name must start with an uppercase letter to make it global.
 func (c *Compiler) SetGlobal(name string, v *big.Int) {
@@ -130,6 +181,24 @@ func (c *Compiler) CompileString(input string) []byte {
 	return c.compile(prog)
 }
 
+// CompileSource compiles the given program text as the content of the named file. The
+// file name is used in error messages, and #include files are resolved relative to it.
+// If compilation fails, the returned slice is nil. Use the Errors method to get
+// parsing/compilation errors.
+//
+// Note the file does not need to exist in the file system set by SetFilesystem.
+// Use Overlay to replace the content of multiple files.
+func (c *Compiler) CompileSource(filename string, input []byte) []byte {
+	defer c.errors.CatchAbort()
+
+	c.reset()
+	prog := c.loader.LoadSource(filename, input)
+	if prog == nil {
+		return nil
+	}
+	return c.compile(prog)
+}
+
 // CompileFile compiles the given program text and returns the corresponding bytecode.
 // If compilation fails, the returned slice is nil. Use the Errors method to get
 // parsing/compilation errors.
@@ -201,28 +270,22 @@ func (c *Compiler) compile(lprog *loader.Program) (output []byte) {
 	// in expressions.
 	e.registerLabels(prog.labels)
 
-	for {
-		prog.computePC()
+	// Compute instruction positions and label-dependent arguments.
+	c.resolveLabels(e, prog)
 
-		// Assign immediate argument values of PUSH. The only argument expressions left to
-		// evaluate here are the ones that use labels somehow. This is self-referential,
-		// the label values depend on the size of the output, which depends on the labels,
-		// etc. To compute the fixed point, the following approach is used:
-		//
-		//   - Each PUSH gets an initial dataSize of one.
-		//   - PC values are assigned based on this assumption
-		//   - We compute all arg values. If any arg size overflows the set dataSize, we bump
-		//     it for this instruction and recompute another round.
-		//   - Otherwise we are done.
-		failedInst, err := c.evaluateArgs(e, prog)
-		if err == nil {
-			break // done
-		} else if errors.Is(err, ecVariablePushOverflow) {
-			failedInst.dataSize += 1
-			continue // recompute after bump
-		} else {
-			c.errors.AddAt(failedInst.ast, err)
-			break // there was some other error
+	// Optimize code layout and drop unreachable code. Since this changes the PC of
+	// instructions, label arguments have to be computed again afterwards.
+	if !c.errors.HasError() {
+		var changed bool
+		if c.doLayout {
+			changed = c.optimizeLayout(prog)
+		}
+		if c.doDeadCode {
+			changed = c.removeDeadCode(prog) || changed
+		}
+		if changed {
+			prog.resetLabelArgs()
+			c.resolveLabels(e, prog)
 		}
 	}
 
@@ -242,7 +305,69 @@ func (c *Compiler) compile(lprog *loader.Program) (output []byte) {
 	}
 
 	// Create the bytecode.
-	return c.generateOutput(prog)
+	output = c.generateOutput(prog)
+	if !c.errors.HasError() {
+		c.lastProg = prog
+	}
+	return output
+}
+
+// resolveLabels assigns the PC values of all instructions and computes the arguments
+// which depend on labels.
+//
+// This is self-referential, the label values depend on the size of the output, which
+// depends on the labels, etc. To compute the fixed point, the following approach is used:
+//
+//   - Each variable-size PUSH starts out with the dataSize assigned by preEvaluateArgs.
+//   - PC values are assigned based on this assumption, and all label-dependent
+//     arguments are computed.
+//   - Any PUSH whose value overflows the set dataSize is grown to fit the value.
+//   - If any instruction has grown, PC values are recomputed, and we compute another
+//     round. Only arguments referencing a label whose PC has changed are evaluated again.
+//   - Otherwise we are done.
+//
+// Since instructions never shrink, a label's PC changes exactly when it is located after
+// an instruction that has grown.
+func (c *Compiler) resolveLabels(e *evaluator, prog *compilerProg) {
+	all := prog.labelArgs()
+	prog.computePC()
+	for pending := all; ; {
+		var (
+			grownPC   = math.MaxInt // lowest PC of a grown instruction
+			failed    = make(set.Set[*instruction])
+			firstErr  error
+			errorInst *instruction
+		)
+		for _, a := range pending {
+			err := c.evaluateLabelArg(e, prog, a)
+			switch {
+			case err == nil:
+			case errors.Is(err, ecVariablePushOverflow):
+				grownPC = min(grownPC, a.inst.pc)
+			default:
+				failed.Add(a.inst)
+				if firstErr == nil {
+					firstErr, errorInst = err, a.inst
+				}
+			}
+		}
+		if grownPC == math.MaxInt {
+			if firstErr != nil {
+				c.errors.AddAt(errorInst.ast, firstErr)
+			}
+			return // done
+		}
+
+		// Select arguments for the next round. Note failed instructions are retried
+		// because the error may be caused by label values which have not converged yet.
+		pending = nil
+		for _, a := range all {
+			if failed.Includes(a.inst) || a.refersAfter(grownPC) {
+				pending = append(pending, a)
+			}
+		}
+		prog.computePC()
+	}
 }
 
 // generateOutput creates the bytecode. This is also where instruction names get resolved.
@@ -32,7 +32,7 @@ func (c *Compiler) checkLabelsUsed(prog *compilerProg, e *evaluator) {
 			continue
 		}
 		seen.Add(l.def)
-		if !e.isLabelUsed(l.def) {
+		if !e.isLabelUsed(l.def) && !prog.removedLabels.Includes(l.def) {
 			c.warnf(l.def, "label %v unused in program", l.def.Ref())
 		}
 	}
@@ -75,31 +75,60 @@ loop:
 	}
 }
 
-// evaluateArgs computes the argument values of instructions.
-func (c *Compiler) evaluateArgs(e *evaluator, prog *compilerProg) (inst *instruction, err error) {
-loop:
+// labelArg is an instruction whose argument depends on labels.
+type labelArg struct {
+	section *compilerSection
+	inst    *instruction
+}
+
+// labelArgs returns all instructions with label-dependent arguments.
+func (prog *compilerProg) labelArgs() []labelArg {
+	var args []labelArg
 	for section, inst := range prog.iterInstructions() {
-		switch {
-		case inst.argNoLabels:
-			// value already assigned by preEvaluateArgs
+		if !inst.argNoLabels && ast.IsPush(inst.op) && inst.expr() != nil {
+			args = append(args, labelArg{section, inst})
+		}
+	}
+	return args
+}
 
-		case isBytes(inst.op):
-			panic("BUG: unevaluated #bytes in evaluateArgs")
+// evaluateLabelArg computes the argument value of an instruction.
+//
+// If the value overflows the dataSize of a variable-size PUSH, the instruction is grown
+// to fit the value, and ecVariablePushOverflow is returned.
+func (c *Compiler) evaluateLabelArg(e *evaluator, prog *compilerProg, a labelArg) error {
+	inst := a.inst
+	var v *big.Int
+	if inst.jumpTarget != nil {
+		// argument replaced by optimizeLayout
+		inst.labelRefs = append(inst.labelRefs[:0], inst.jumpTarget)
+		v = big.NewInt(int64(inst.jumpTarget.pc))
+	} else {
+		e.labelRefs = e.labelRefs[:0]
+		result, err := e.eval(inst.expr(), a.section.env)
+		inst.labelRefs = append(inst.labelRefs[:0], e.labelRefs...)
+		if err != nil {
+			return err
+		}
+		v = result.Int()
+	}
 
-		case ast.IsPush(inst.op):
-			if inst.expr() == nil {
-				continue loop // push0
-			}
-			v, err := e.eval(inst.expr(), section.env)
-			if err != nil {
-				return inst, err
-			}
-			if err := prog.assignPushArg(inst, v.Int(), false); err != nil {
-				return inst, err
-			}
+	err := prog.assignPushArg(inst, v, false)
+	if errors.Is(err, ecVariablePushOverflow) {
+		inst.dataSize = prog.autoPushSize(v.Bytes())
+		inst.data = v.Bytes()
+	}
+	return err
+}
+
+// refersAfter reports whether the argument references a label located after pc.
+func (a labelArg) refersAfter(pc int) bool {
+	for _, ref := range a.inst.labelRefs {
+		if ref.pc > pc {
+			return true
 		}
 	}
-	return nil, nil
+	return false
 }
 
 // assignPushArg sets the argument value of an instruction to v. The byte size of the
@@ -133,6 +162,20 @@ func (prog *compilerProg) assignPushArg(inst *instruction, v *big.Int, setSize b
 	return nil
 }
 
+// resetLabelArgs restores the initial dataSize of variable-size PUSH instructions whose
+// argument depends on labels. This is used when the program layout has changed, to allow
+// the arguments to shrink.
+func (prog *compilerProg) resetLabelArgs() {
+	for _, inst := range prog.iterInstructions() {
+		if inst.argNoLabels || inst.op != "PUSH" {
+			continue
+		}
+		if _, ok := inst.explicitPushSize(); !ok {
+			inst.dataSize = 1
+		}
+	}
+}
+
 func (prog *compilerProg) autoPushSize(value []byte) int {
 	if len(value) > 32 {
 		panic("value too big")
@@ -0,0 +1,360 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"github.com/fjl/geas/internal/ast"
+	"github.com/fjl/geas/internal/evm"
+	"github.com/fjl/geas/internal/set"
+)
+
+// codeBlock is a basic block of the compiler output program.
+type codeBlock struct {
+	instr    []*instruction
+	section  *compilerSection // section of all instructions, nil if block spans sections
+	hasBytes bool             // block contains #bytes
+	term     bool             // block ends with terminal instruction or unconditional jump
+}
+
+// splitCodeBlocks divides the instructions of the program into basic blocks. A block
+// starts at each JUMPDEST, and ends after a terminal instruction or unconditional jump.
+//
+// This also returns the mapping of instructions to block indexes.
+func (prog *compilerProg) splitCodeBlocks() ([]*codeBlock, map[*instruction]int) {
+	var (
+		blocks  []*codeBlock
+		blockOf = make(map[*instruction]int)
+		cur     = new(codeBlock)
+	)
+	finish := func() {
+		if len(cur.instr) > 0 {
+			blocks = append(blocks, cur)
+		}
+		cur = new(codeBlock)
+	}
+	for section, inst := range prog.iterInstructions() {
+		if inst.op == "JUMPDEST" {
+			finish()
+		}
+		blockOf[inst] = len(blocks)
+		if len(cur.instr) == 0 {
+			cur.section = section
+		} else if cur.section != section {
+			cur.section = nil
+		}
+		cur.instr = append(cur.instr, inst)
+		if isBytes(inst.op) {
+			cur.hasBytes = true
+		}
+		if op := prog.Fork.OpByName(inst.op); op != nil && (op.Term || op.Unconditional) {
+			cur.term = true
+			finish()
+		}
+	}
+	finish()
+	return blocks, blockOf
+}
+
+// removeDeadCode drops basic blocks that cannot be reached by execution.
+// It reports whether any instructions were removed.
+//
+// This must run after label arguments have been evaluated, because the label references
+// of instruction arguments are used to find the reachable blocks.
+func (c *Compiler) removeDeadCode(prog *compilerProg) bool {
+	blocks, blockOf := prog.splitCodeBlocks()
+	if len(blocks) == 0 {
+		return false
+	}
+
+	// Find live blocks. Execution starts in the first block, and from there it reaches
+	// the next block by fall-through, as well as any block containing an instruction
+	// referenced by a label in the arguments. Label references made by dead code
+	// are not considered.
+	live := make([]bool, len(blocks))
+	worklist := []int{0}
+	mark := func(i int) {
+		if !live[i] {
+			live[i] = true
+			worklist = append(worklist, i)
+		}
+	}
+	live[0] = true
+	for i, b := range blocks {
+		if b.hasBytes {
+			mark(i)
+		}
+	}
+	for len(worklist) > 0 {
+		i := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		if !blocks[i].term && i+1 < len(blocks) {
+			mark(i + 1)
+		}
+		for _, inst := range blocks[i].instr {
+			for _, ref := range inst.labelRefs {
+				if b, ok := blockOf[ref]; ok {
+					mark(b)
+				}
+			}
+		}
+	}
+
+	// Remove the instructions of dead blocks.
+	dead := make(set.Set[*instruction])
+	for i, b := range blocks {
+		if !live[i] {
+			for _, inst := range b.instr {
+				dead.Add(inst)
+			}
+		}
+	}
+	if len(dead) == 0 {
+		return false
+	}
+
+	// The JUMPDEST of a removed block may be the target of a computed jump, which
+	// cannot be seen here. Report it, so the removal doesn't go unnoticed. Trampolines
+	// bypassed by the layout optimizer are not reported.
+	prog.removedLabels = make(set.Set[*ast.LabelDef])
+	for _, l := range prog.labels {
+		if l.def.Dotted || !dead.Includes(l.instr) || prog.threaded.Includes(l.instr) {
+			continue
+		}
+		if !prog.removedLabels.Includes(l.def) {
+			c.warnf(l.def, "removed unreachable code at label %v", l.def.Ref())
+			prog.removedLabels.Add(l.def)
+		}
+	}
+	prog.removeInstructions(dead)
+	return true
+}
+
+// optimizeLayout rearranges the program to reduce the number of jumps executed.
+// It reports whether the program was modified.
+//
+// Three transformations are applied:
+//
+//   - Jumps to a trampoline block, i.e. a block that consists of nothing but another
+//     jump, are redirected to the final destination.
+//   - When a block ends in a jump to a movable block, the target block is moved to
+//     immediately follow the jump.
+//   - Jumps to the immediately following instruction are removed.
+//
+// Like removeDeadCode, this must run after label arguments have been evaluated.
+func (c *Compiler) optimizeLayout(prog *compilerProg) bool {
+	threaded := prog.threadJumps()
+	moved := prog.chainBlocks()
+	removed := prog.removeJumpsToNext()
+	return threaded || moved || removed
+}
+
+// jumpDest returns the instruction targeted by a PUSH instruction when its argument is a
+// plain reference to a JUMPDEST label. Otherwise, it returns nil.
+func (inst *instruction) jumpDest() *instruction {
+	if inst.jumpTarget != nil {
+		return inst.jumpTarget
+	}
+	if !ast.IsPush(inst.op) || len(inst.labelRefs) != 1 {
+		return nil
+	}
+	lref, ok := inst.expr().(*ast.LabelRefExpr)
+	if !ok || lref.Dotted || inst.labelRefs[0].op != "JUMPDEST" {
+		return nil
+	}
+	return inst.labelRefs[0]
+}
+
+// blockJump returns the jump at the end of a block, along with the PUSH of its
+// destination. If the block does not end in an unconditional jump to a label, it
+// returns nil.
+func (b *codeBlock) blockJump() (push, jump *instruction) {
+	n := len(b.instr)
+	if n < 2 || b.instr[n-1].op != "JUMP" {
+		return nil, nil
+	}
+	push = b.instr[n-2]
+	if push.jumpDest() == nil {
+		return nil, nil
+	}
+	return push, b.instr[n-1]
+}
+
+// isTrampoline reports whether the block consists only of a JUMPDEST followed by an
+// unconditional jump to a label. Empty instructions, such as dotted labels, are ignored.
+func (b *codeBlock) isTrampoline() bool {
+	push, _ := b.blockJump()
+	if push == nil {
+		return false
+	}
+	var ops []*instruction
+	for _, inst := range b.instr {
+		if inst.op != "" {
+			ops = append(ops, inst)
+		}
+	}
+	return len(ops) == 3 && ops[0].op == "JUMPDEST"
+}
+
+// threadJumps redirects jumps which target a trampoline block.
+func (prog *compilerProg) threadJumps() bool {
+	blocks, blockOf := prog.splitCodeBlocks()
+	if prog.threaded == nil {
+		prog.threaded = make(set.Set[*instruction])
+	}
+
+	// final follows the chain of trampolines starting at dest.
+	final := func(dest *instruction) *instruction {
+		seen := make(set.Set[*instruction])
+		for !seen.Includes(dest) {
+			seen.Add(dest)
+			b := blocks[blockOf[dest]]
+			if b.instr[0] != dest || !b.isTrampoline() {
+				break
+			}
+			push, _ := b.blockJump()
+			prog.threaded.Add(dest)
+			dest = push.jumpDest()
+		}
+		return dest
+	}
+
+	var changed bool
+	for _, b := range blocks {
+		for i, inst := range b.instr {
+			if i+1 >= len(b.instr) || !evm.IsJump(b.instr[i+1].op) {
+				continue
+			}
+			dest := inst.jumpDest()
+			if dest == nil {
+				continue
+			}
+			if target := final(dest); target != dest {
+				inst.jumpTarget = target
+				inst.labelRefs = append(inst.labelRefs[:0], target)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// chainBlocks moves jump targets to directly follow the jump.
+//
+// A block can only be moved if execution never falls through into it or out of it,
+// i.e. the preceding block and the block itself must end in a terminal instruction or
+// unconditional jump. The block must also begin with a JUMPDEST, and all of its
+// instructions must belong to the same section as the jump. Blocks containing #bytes
+// are never moved.
+//
+// Moving a block changes the distance between labels, so a block is also kept in
+// place when any of its labels, or a label just after its end, is referenced by
+// anything other than a jump.
+func (prog *compilerProg) chainBlocks() bool {
+	blocks, blockOf := prog.splitCodeBlocks()
+	pinned := prog.nonJumpRefs(blocks)
+	movable := func(i int) bool {
+		b := blocks[i]
+		if i+1 < len(blocks) && pinned.Includes(blocks[i+1].instr[0]) {
+			return false
+		}
+		for _, inst := range b.instr {
+			if pinned.Includes(inst) {
+				return false
+			}
+		}
+		return i > 0 && blocks[i-1].term && b.term && !b.hasBytes && b.section != nil &&
+			b.instr[0].op == "JUMPDEST"
+	}
+
+	var (
+		placed  = make([]bool, len(blocks))
+		changed bool
+	)
+	for i := range blocks {
+		if placed[i] {
+			continue
+		}
+		placed[i] = true
+		for prev := i; ; {
+			push, jump := blocks[prev].blockJump()
+			if push == nil {
+				break
+			}
+			next := blockOf[push.jumpDest()]
+			if placed[next] || !movable(next) || blocks[next].section != blocks[prev].section {
+				break
+			}
+			// Blocks are only ever inserted after a jump, so a block which has not
+			// been moved is still followed by its original successor.
+			if prev != i || next != prev+1 {
+				prog.moveInstructionsAfter(blocks[next].instr, jump)
+				changed = true
+			}
+			placed[next] = true
+			prev = next
+		}
+	}
+	return changed
+}
+
+// nonJumpRefs returns the instructions referenced by label arguments, except for
+// those made by the 'push @label; jump' pattern.
+func (prog *compilerProg) nonJumpRefs(blocks []*codeBlock) set.Set[*instruction] {
+	refs := make(set.Set[*instruction])
+	for _, b := range blocks {
+		for i, inst := range b.instr {
+			isJump := i+1 < len(b.instr) && evm.IsJump(b.instr[i+1].op) && inst.jumpDest() != nil
+			for _, ref := range inst.labelRefs {
+				if !isJump {
+					refs.Add(ref)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// removeJumpsToNext deletes unconditional jumps to the immediately following instruction.
+func (prog *compilerProg) removeJumpsToNext() bool {
+	var (
+		rm           = make(set.Set[*instruction])
+		next         = make(map[*instruction]*instruction)
+		prev1, prev2 *instruction // previous two instructions
+	)
+	for _, inst := range prog.iterInstructions() {
+		if inst.op == "" {
+			continue
+		}
+		if prev2 != nil && prev1.op == "JUMP" && prev2.jumpDest() == inst {
+			rm.Add(prev2)
+			rm.Add(prev1)
+			next[prev2], next[prev1] = inst, inst
+		}
+		prev1, prev2 = inst, prev1
+	}
+	if len(rm) == 0 {
+		return false
+	}
+	// Labels of the removed jump now point to its destination.
+	for _, l := range prog.labels {
+		if n, ok := next[l.instr]; ok {
+			l.instr = n
+		}
+	}
+	prog.removeInstructions(rm)
+	return true
+}
@@ -19,9 +19,11 @@ package asm
 import (
 	"fmt"
 	"iter"
+	"slices"
 
 	"github.com/fjl/geas/internal/ast"
 	"github.com/fjl/geas/internal/loader"
+	"github.com/fjl/geas/internal/set"
 )
 
 // compilerProg is the output program of the compiler.
@@ -47,6 +49,11 @@ type compilerProg struct {
 
 	// Here we track the instantiations of global labels.
 	globalLabels map[string]*compilerLabel
+
+	// Optimizer state: trampolines bypassed by jump threading, and the labels
+	// of code removed as unreachable.
+	threaded      set.Set[*instruction]
+	removedLabels set.Set[*ast.LabelDef]
 }
 
 // compilerSection is a section of the output program.
@@ -188,6 +195,48 @@ func (p *compilerProg) iterInstructions() iter.Seq2[*compilerSection, *instructi
 	}
 }
 
+// liveLabels returns the labels which point to an instruction of the program. Labels
+// of code removed by the optimizer are skipped.
+func (p *compilerProg) liveLabels() []*compilerLabel {
+	live := make(set.Set[*instruction])
+	for _, inst := range p.iterInstructions() {
+		live.Add(inst)
+	}
+	var labels []*compilerLabel
+	for _, l := range p.labels {
+		if l.instr != nil && live.Includes(l.instr) {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+// removeInstructions deletes the given instructions from the program.
+// Section boundaries are retained.
+func (p *compilerProg) removeInstructions(rm set.Set[*instruction]) {
+	p.elems = slices.DeleteFunc(p.elems, func(elem any) bool {
+		inst, ok := elem.(*instruction)
+		return ok && rm.Includes(inst)
+	})
+}
+
+// moveInstructionsAfter moves the given instructions to directly follow dest.
+// The moved instructions become part of the section containing dest.
+func (p *compilerProg) moveInstructionsAfter(insts []*instruction, dest *instruction) {
+	rm := make(set.Set[*instruction], len(insts))
+	elems := make([]any, len(insts))
+	for j, inst := range insts {
+		rm.Add(inst)
+		elems[j] = inst
+	}
+	p.removeInstructions(rm)
+	i := slices.Index(p.elems, any(dest))
+	if i < 0 {
+		panic("BUG: move destination not in program")
+	}
+	p.elems = slices.Insert(p.elems, i+1, elems...)
+}
+
 // computePC assigns the PC values of all instructions and labels.
 func (p *compilerProg) computePC() {
 	var pc int
@@ -217,6 +266,13 @@ type instruction struct {
 	dataSize    int    // computed size of data field
 	data        []byte // computed argument value
 	argNoLabels bool   // true if arg expression does not contain @label
+
+	// label instructions referenced by the arg expression, assigned by evaluateArgs
+	labelRefs []*instruction
+
+	// jumpTarget overrides the argument of a PUSH. When set, the argument
+	// is the PC of the target instruction. This is assigned by optimizeLayout.
+	jumpTarget *instruction
 }
 
 func newInstruction(ast statement, op string) *instruction {
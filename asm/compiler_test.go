@@ -19,6 +19,7 @@ package asm
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"maps"
 	"math/big"
 	"os"
@@ -35,6 +36,7 @@ type compilerTestInput struct {
 	Code    string              `yaml:"code"`
 	Files   map[string]string   `yaml:"files,omitempty"`
 	Globals map[string]*big.Int `yaml:"globals,omitempty"`
+	Options []string            `yaml:"options,omitempty"`
 }
 
 type compilerTestOutput struct {
@@ -56,6 +58,10 @@ func TestStackCheck(t *testing.T) {
 	runCompilerTests(t, "stackcheck-tests.yaml")
 }
 
+func TestOptimizer(t *testing.T) {
+	runCompilerTests(t, "optimizer-tests.yaml")
+}
+
 func runCompilerTests(t *testing.T, file string) {
 	content, err := os.ReadFile(filepath.Join("testdata", file))
 	if err != nil {
@@ -84,6 +90,16 @@ func runCompilerTests(t *testing.T, file string) {
 				c.SetGlobal(name, val)
 			}
 			c.SetStackCheck(true)
+			for _, opt := range test.Input.Options {
+				switch opt {
+				case "dce":
+					c.SetDeadCodeElimination(true)
+				case "layout":
+					c.SetLayoutOptimization(true)
+				default:
+					t.Fatalf("unknown option %q", opt)
+				}
+			}
 
 			output := c.CompileString(test.Input.Code)
 
@@ -189,3 +205,70 @@ func compileExample(t *testing.T, exampleDir string, file string) string {
 	}
 	return hex.EncodeToString(output)
 }
+
+// This test checks label resolution in a program where many pushes grow at once.
+func TestLargeJumpTable(t *testing.T) {
+	c := New(nil)
+	output := c.CompileString(jumpTableProgram(300))
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	// The table has 300 pushes. The dispatch code is followed by 300 blocks of two bytes,
+	// so all labels are beyond PC 256 and need a two-byte push.
+	const tableSize = 300 * 3
+	if len(output) != tableSize+1+300*2 {
+		t.Fatalf("wrong output size %d", len(output))
+	}
+	for i := range 300 {
+		push := output[i*3 : i*3+3]
+		wantPC := tableSize + 1 + i*2
+		if push[0] != 0x61 || int(push[1])<<8|int(push[2]) != wantPC {
+			t.Fatalf("wrong push %d: %x, want pc %d", i, push, wantPC)
+		}
+	}
+}
+
+func BenchmarkCompileLabels(b *testing.B) {
+	for _, n := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("jumptable-%d", n), func(b *testing.B) {
+			benchmarkCompile(b, jumpTableProgram(n))
+		})
+		b.Run(fmt.Sprintf("forwardjumps-%d", n), func(b *testing.B) {
+			benchmarkCompile(b, forwardJumpsProgram(n))
+		})
+	}
+}
+
+func benchmarkCompile(b *testing.B, code string) {
+	c := New(nil)
+	b.ReportAllocs()
+	for b.Loop() {
+		if c.CompileString(code); c.Failed() {
+			b.Fatal(c.Errors())
+		}
+	}
+}
+
+// jumpTableProgram creates a program which pushes n labels, followed by the code of
+// the label blocks.
+func jumpTableProgram(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		fmt.Fprintf(&sb, "push @l%d\n", i)
+	}
+	sb.WriteString("stop\n")
+	for i := range n {
+		fmt.Fprintf(&sb, "l%d:\n  stop\n", i)
+	}
+	return sb.String()
+}
+
+// forwardJumpsProgram creates a chain of n blocks, where each block jumps to the next one.
+func forwardJumpsProgram(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		fmt.Fprintf(&sb, "b%d:\n  jump @b%d\n", i, i+1)
+	}
+	fmt.Fprintf(&sb, "b%d:\n  stop\n", n)
+	return sb.String()
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/fjl/geas/internal/ast"
+	"github.com/fjl/geas/internal/stack"
+)
+
+// DebugInfo describes how the bytecode output of a compilation relates to the source code.
+type DebugInfo struct {
+	Fork         string             // name of the instruction set
+	Instructions []DebugInstruction // all instructions, ordered by PC
+	Labels       []DebugLabel       // all label instantiations, in program order
+}
+
+// DebugInstruction describes an instruction of the output program.
+type DebugInstruction struct {
+	PC   int
+	Size int          // byte size, including immediate data
+	Op   string       // opcode name, or "#bytes" for data
+	Pos  ast.Position // location of the source statement
+
+	// Expansion is the chain of instruction macro calls and #include statements that
+	// led to the instruction, innermost first.
+	Expansion []ExpansionFrame
+
+	// StackComment is the text of the stack comment on the source line, if any.
+	StackComment string
+}
+
+// StackItems returns the stack item names declared by the stack comment, top first.
+// It returns nil if the instruction has no valid stack comment.
+func (di *DebugInstruction) StackItems() []string {
+	if di.StackComment == "" {
+		return nil
+	}
+	items, err := stack.ParseComment(di.StackComment)
+	if err != nil {
+		return nil
+	}
+	items, _ = stack.StripWildcard(items)
+	return items
+}
+
+// ExpansionFrame is an instruction macro call or #include statement.
+type ExpansionFrame struct {
+	Macro   string       // macro name, set for macro calls
+	Include string       // included file, set for #include
+	Pos     ast.Position // location of the call or #include
+}
+
+// DebugLabel describes a label of the output program.
+type DebugLabel struct {
+	Name   string
+	Dotted bool
+	PC     int
+	Pos    ast.Position // location of the definition
+}
+
+// DebugInfo returns debug information for the most recent compilation.
+// It returns nil if there was no successful compilation.
+func (c *Compiler) DebugInfo() *DebugInfo {
+	if c.lastProg == nil {
+		return nil
+	}
+	prog := c.lastProg
+	info := &DebugInfo{Fork: prog.Fork.Name()}
+
+	frames := make(map[*compilerSection][]ExpansionFrame)
+	for section, inst := range prog.iterInstructions() {
+		size := inst.encodedSize()
+		if size == 0 {
+			continue
+		}
+		di := DebugInstruction{
+			PC:        inst.pc,
+			Size:      size,
+			Op:        prog.opName(inst),
+			Pos:       statementPosition(inst.ast),
+			Expansion: sectionFrames(section, frames),
+		}
+		if cmt := inst.ast.Comment(); cmt != nil && cmt.IsStackComment() {
+			di.StackComment = cmt.InnerText()
+		}
+		info.Instructions = append(info.Instructions, di)
+	}
+	for _, l := range prog.liveLabels() {
+		info.Labels = append(info.Labels, DebugLabel{
+			Name:   l.def.Ident,
+			Dotted: l.def.Dotted,
+			PC:     l.instr.pc,
+			Pos:    l.def.Position(),
+		})
+	}
+	return info
+}
+
+// opName returns the name of the opcode of an instruction.
+func (prog *compilerProg) opName(inst *instruction) string {
+	if inst.op == "PUSH" {
+		if op := prog.Fork.PushBySize(inst.dataSize); op != nil {
+			return op.Name
+		}
+	}
+	return inst.op
+}
+
+// sectionFrames computes the expansion chain of a section.
+func sectionFrames(s *compilerSection, cache map[*compilerSection][]ExpansionFrame) []ExpansionFrame {
+	if s == nil {
+		return nil
+	}
+	if f, ok := cache[s]; ok {
+		return f
+	}
+	var frame *ExpansionFrame
+	switch st := s.doc.Creation.(type) {
+	case macroCallStatement:
+		frame = &ExpansionFrame{Macro: st.Ident, Pos: st.Position()}
+	case *ast.Include:
+		frame = &ExpansionFrame{Include: s.doc.File, Pos: st.Position()}
+	}
+	parent := sectionFrames(s.parent, cache)
+	var f []ExpansionFrame
+	if frame != nil {
+		f = append([]ExpansionFrame{*frame}, parent...)
+	} else {
+		f = parent
+	}
+	cache[s] = f
+	return f
+}
+
+// statementPosition returns the position of st. For synthetic statements, which are not
+// part of any document, the zero position is returned.
+func statementPosition(st ast.Statement) ast.Position {
+	if d, ok := st.(interface{ Document() *ast.Document }); ok && d.Document() == nil {
+		return ast.Position{}
+	}
+	return st.Position()
+}
+
+// InstructionAt returns the instruction containing the given program counter.
+// It returns nil if pc is not within the program.
+func (info *DebugInfo) InstructionAt(pc int) *DebugInstruction {
+	i, found := slices.BinarySearchFunc(info.Instructions, pc, func(di DebugInstruction, pc int) int {
+		return cmp.Compare(di.PC, pc)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 || i >= len(info.Instructions) {
+		return nil
+	}
+	if di := &info.Instructions[i]; pc < di.PC+di.Size {
+		return di
+	}
+	return nil
+}
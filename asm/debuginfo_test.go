@@ -0,0 +1,45 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"testing"
+)
+
+// This checks that labels of code removed by dead code elimination are not
+// included in the debug info.
+func TestDebugInfoDeadCode(t *testing.T) {
+	src := `
+    push 1
+    jump @a
+b:
+    push 2
+    stop
+a:
+    stop
+`
+	c := New(nil)
+	c.SetDeadCodeElimination(true)
+	c.CompileString(src)
+	if len(c.Errors()) > 0 {
+		t.Fatal(c.Errors())
+	}
+	labels := c.DebugInfo().Labels
+	if len(labels) != 1 || labels[0].Name != "a" || labels[0].PC != 5 {
+		t.Errorf("wrong labels %+v", labels)
+	}
+}
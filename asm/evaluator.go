@@ -33,7 +33,8 @@ type evaluator struct {
 	inStack     map[*ast.ExpressionMacroDef]struct{}
 	labelInstr  map[evalLabelKey]*instruction
 	usedLabels  map[*ast.LabelDef]struct{}
-	compiler    *Compiler // for assemble macro
+	labelRefs   []*instruction // label instructions referenced by the current evaluation
+	compiler    *Compiler      // for assemble macro
 	cache       evalCache
 	labelsValid bool // while false, evaluating labels returns unassignedLabelErr
 }
@@ -156,6 +157,7 @@ func (e *evaluator) lookupLabel(env *evalEnvironment, lref *ast.LabelRefExpr) (p
 	}
 	// mark label used (for unused label analysis)
 	e.usedLabels[li] = struct{}{}
+	e.labelRefs = append(e.labelRefs, instr)
 	return instr.pc, true, nil
 }
 
@@ -206,6 +206,7 @@ func assembleMacro(e *evaluator, env *evalEnvironment, call *ast.MacroCallExpr)
 	subc := New(e.compiler.loader.Filesystem())
 	subc.SetDefaultFork(env.prog.Fork.Name())
 	subc.SetIncludeDepthLimit(e.compiler.loader.MaxIncludeDepth())
+	subc.loader.SetParseCache(e.compiler.loader.ParseCache())
 	subc.macroOverrides = e.overrides
 	file, err := loader.ResolveRelative(env.doc.File, string(filename))
 	if err != nil {
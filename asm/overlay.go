@@ -0,0 +1,107 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Overlay is a file system that replaces selected files of an underlying file system with
+// in-memory contents. This can be used to compile the unsaved content of editor buffers,
+// while #include still resolves other files from disk.
+//
+// Overlay is safe for concurrent use.
+type Overlay struct {
+	base  fs.FS
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewOverlay creates an overlay on top of base. If base is nil, only the files added to
+// the overlay can be opened.
+func NewOverlay(base fs.FS) *Overlay {
+	return &Overlay{base: base, files: make(map[string][]byte)}
+}
+
+// Set replaces the content of the named file. The name uses the path syntax of fs.FS,
+// i.e. it is slash-separated and relative to the root of the file system.
+func (o *Overlay) Set(name string, content []byte) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "set", Path: name, Err: fs.ErrInvalid}
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.files[name] = bytes.Clone(content)
+	return nil
+}
+
+// Remove deletes the named file from the overlay. The underlying file becomes visible again.
+func (o *Overlay) Remove(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.files, name)
+}
+
+// Open opens the named file.
+func (o *Overlay) Open(name string) (fs.File, error) {
+	if content, ok := o.overlayFile(name); ok {
+		return &overlayFile{name: path.Base(name), Reader: bytes.NewReader(content)}, nil
+	}
+	if o.base == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return o.base.Open(name)
+}
+
+// ReadFile reads the named file.
+func (o *Overlay) ReadFile(name string) ([]byte, error) {
+	if content, ok := o.overlayFile(name); ok {
+		return slices.Clone(content), nil
+	}
+	if o.base == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return fs.ReadFile(o.base, name)
+}
+
+func (o *Overlay) overlayFile(name string) ([]byte, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	content, ok := o.files[name]
+	return content, ok
+}
+
+// overlayFile is an open in-memory file.
+type overlayFile struct {
+	name string
+	*bytes.Reader
+}
+
+func (f *overlayFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f *overlayFile) Close() error               { return nil }
+
+// fs.FileInfo implementation
+func (f *overlayFile) Name() string       { return f.name }
+func (f *overlayFile) Mode() fs.FileMode  { return 0444 }
+func (f *overlayFile) ModTime() time.Time { return time.Time{} }
+func (f *overlayFile) IsDir() bool        { return false }
+func (f *overlayFile) Sys() any           { return nil }
@@ -0,0 +1,73 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOverlay(t *testing.T) {
+	base := fstest.MapFS{
+		"contracts/main.eas":      {Data: []byte(`#include "lib/const.eas"` + "\npush Value\n")},
+		"contracts/lib/const.eas": {Data: []byte("#define Value = 1\n")},
+	}
+	o := NewOverlay(base)
+	if err := o.Set("contracts/lib/const.eas", []byte("#define Value = 2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(o)
+	if output := c.CompileFile("contracts/main.eas"); !bytes.Equal(output, []byte{0x60, 0x02}) {
+		t.Errorf("wrong output %x with overlay", output)
+	}
+	o.Remove("contracts/lib/const.eas")
+	if output := c.CompileFile("contracts/main.eas"); !bytes.Equal(output, []byte{0x60, 0x01}) {
+		t.Errorf("wrong output %x after Remove", output)
+	}
+
+	if err := o.Set("../x.eas", nil); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("wrong error for invalid path: %v", err)
+	}
+	if _, err := o.Open("missing.eas"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("wrong error for missing file: %v", err)
+	}
+}
+
+func TestCompileSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"contracts/lib/const.eas": {Data: []byte("#define Value = 3\n")},
+	}
+	c := New(fsys)
+	output := c.CompileSource("contracts/main.eas", []byte(`#include "lib/const.eas"`+"\npush Value\n"))
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	if !bytes.Equal(output, []byte{0x60, 0x03}) {
+		t.Errorf("wrong output %x", output)
+	}
+
+	// Errors should carry the file name.
+	c.CompileSource("contracts/main.eas", []byte("push\n"))
+	errs := c.Errors()
+	if len(errs) != 1 || errs[0].Error() != "contracts/main.eas:1:0: PUSH requires an immediate argument" {
+		t.Errorf("wrong errors: %v", errs)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"fmt"
+
+	"github.com/fjl/geas/internal/ast"
+)
+
+// Symbols is the symbol table of a compiled program. It is meant to be stored as JSON
+// next to the bytecode. The disassembler uses it to restore label names and data
+// regions when disassembling the program.
+type Symbols struct {
+	Fork   string        `json:"fork,omitempty"`
+	Labels []SymbolLabel `json:"labels"`
+	Data   []SymbolData  `json:"data,omitempty"`
+	Refs   []SymbolRef   `json:"refs,omitempty"`
+}
+
+// SymbolLabel is a label of the program. Label names are unique within the table.
+// Labels with the same name, e.g. from multiple expansions of a macro, are
+// renamed by adding a numeric suffix.
+type SymbolLabel struct {
+	Name   string `json:"name"`
+	PC     int    `json:"pc"`
+	Dotted bool   `json:"dotted,omitempty"`
+}
+
+// SymbolData is a region of the program created by #bytes. Name is set if the region
+// was defined as named bytes.
+type SymbolData struct {
+	PC   int    `json:"pc"`
+	Size int    `json:"size"`
+	Name string `json:"name,omitempty"`
+}
+
+// SymbolRef is a PUSH instruction whose argument is a plain label reference,
+// like 'push @label' or 'jump @label'.
+type SymbolRef struct {
+	PC    int    `json:"pc"`
+	Label string `json:"label"`
+}
+
+// Symbols returns the symbol table of the most recent compilation.
+// It returns nil if there was no successful compilation.
+func (c *Compiler) Symbols() *Symbols {
+	if c.lastProg == nil {
+		return nil
+	}
+	prog := c.lastProg
+	syms := &Symbols{Fork: prog.Fork.Name()}
+
+	// Assign unique label names.
+	type bytesKey struct {
+		def *ast.LabelDef
+		pc  int
+	}
+	var (
+		taken     = make(map[string]bool)
+		names     = make(map[*instruction]string)
+		bytesName = make(map[bytesKey]string)
+		labelPC   = make(map[int]bool)
+	)
+	for _, l := range prog.labels {
+		taken[l.def.Ident] = true
+	}
+	seen := make(map[string]bool)
+	for _, l := range prog.liveLabels() {
+		name := l.def.Ident
+		if seen[name] {
+			for i := 1; taken[name]; i++ {
+				name = fmt.Sprintf("%s_%d", l.def.Ident, i)
+			}
+			taken[name] = true
+		}
+		seen[l.def.Ident] = true
+		names[l.instr] = name
+		bytesName[bytesKey{l.def, l.instr.pc}] = name
+		labelPC[l.instr.pc] = true
+		syms.Labels = append(syms.Labels, SymbolLabel{Name: name, PC: l.instr.pc, Dotted: l.def.Dotted})
+	}
+
+	// Collect data regions and label references.
+	for _, inst := range prog.iterInstructions() {
+		switch {
+		case isBytes(inst.op):
+			size := inst.encodedSize()
+			if size == 0 {
+				continue
+			}
+			var name string
+			if st, ok := inst.ast.(bytesStatement); ok && st.Label != nil {
+				name = bytesName[bytesKey{st.Label, inst.pc}]
+			}
+			// Unnamed #bytes are merged with a preceding unnamed region, unless
+			// a label separates them.
+			if n := len(syms.Data); n > 0 && name == "" && !labelPC[inst.pc] {
+				if prev := &syms.Data[n-1]; prev.Name == "" && prev.PC+prev.Size == inst.pc {
+					prev.Size += size
+					continue
+				}
+			}
+			syms.Data = append(syms.Data, SymbolData{PC: inst.pc, Size: size, Name: name})
+
+		case ast.IsPush(inst.op) && len(inst.labelRefs) == 1:
+			name, ok := names[inst.labelRefs[0]]
+			if !ok {
+				continue
+			}
+			if _, isRef := inst.expr().(*ast.LabelRefExpr); isRef || inst.jumpTarget != nil {
+				syms.Refs = append(syms.Refs, SymbolRef{PC: inst.pc, Label: name})
+			}
+		}
+	}
+	return syms
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSymbols(t *testing.T) {
+	src := `
+#define %skip() {
+    jump @over
+over:
+}
+    %skip()
+    %skip()
+    push @data
+    push @.end
+    stop
+#bytes data: 0x0102
+#bytes 0x03
+#bytes 0x04
+.end:
+`
+	c := New(nil)
+	c.CompileString(src)
+	if len(c.Errors()) > 0 {
+		t.Fatal(c.Errors())
+	}
+	syms := c.Symbols()
+	want := &Symbols{
+		Fork: syms.Fork,
+		Labels: []SymbolLabel{
+			{Name: "over", PC: 3},
+			{Name: "over_1", PC: 7},
+			{Name: "data", PC: 13, Dotted: true},
+			{Name: "end", PC: 17, Dotted: true},
+		},
+		Data: []SymbolData{
+			{PC: 13, Size: 2, Name: "data"},
+			{PC: 15, Size: 2},
+		},
+		Refs: []SymbolRef{
+			{PC: 0, Label: "over"},
+			{PC: 4, Label: "over_1"},
+			{PC: 8, Label: "data"},
+			{PC: 10, Label: "end"},
+		},
+	}
+	if !reflect.DeepEqual(syms, want) {
+		t.Errorf("wrong symbols\ngot:  %+v\nwant: %+v", syms, want)
+	}
+}
+
+// This checks that labels of code removed by dead code elimination are not
+// included in the symbol table.
+func TestSymbolsDeadCode(t *testing.T) {
+	src := `
+    push 1
+    jump @a
+b:
+    push 2
+    stop
+a:
+    stop
+`
+	c := New(nil)
+	c.SetDeadCodeElimination(true)
+	c.CompileString(src)
+	if len(c.Errors()) > 0 {
+		t.Fatal(c.Errors())
+	}
+	want := []SymbolLabel{{Name: "a", PC: 5}}
+	if labels := c.Symbols().Labels; !reflect.DeepEqual(labels, want) {
+		t.Errorf("wrong labels\ngot:  %+v\nwant: %+v", labels, want)
+	}
+}
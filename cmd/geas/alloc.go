@@ -0,0 +1,53 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/fjl/geas/internal/alloc"
+)
+
+func allocGenerator(args []string) {
+	var (
+		fs      = newFlagSet("-alloc")
+		outFile = fs.String("o", "", "")
+	)
+	parseFlags(fs, args)
+
+	fsys, file := openSourceRoot(fileArg(fs))
+	m, err := alloc.Load(fsys, file)
+	if err != nil {
+		exit(2, err)
+	}
+	ga, err := m.Build(fsys)
+	if err != nil {
+		exit(1, err)
+	}
+	enc, err := json.MarshalIndent(ga, "", "  ")
+	if err != nil {
+		exit(1, err)
+	}
+	enc = append(enc, '\n')
+
+	if *outFile == "" {
+		os.Stdout.Write(enc)
+	} else if err := os.WriteFile(*outFile, enc, 0644); err != nil {
+		exit(1, err)
+	}
+}
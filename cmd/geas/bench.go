@@ -0,0 +1,122 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/fjl/geas/internal/testfile"
+)
+
+func benchmarker(args []string) {
+	var (
+		fs        = newFlagSet("-bench")
+		snapFile  = fs.String("snapshot", ".gas-snapshot", "")
+		check     = fs.Bool("check", false, "")
+		tolerance = fs.Float64("tolerance", 0, "")
+	)
+	parseFlags(fs, args)
+	if *tolerance < 0 {
+		exit(2, fmt.Errorf("tolerance must not be negative"))
+	}
+
+	fsys, files := discoverTestFiles(fs.Args())
+	snap := make(testfile.Snapshot)
+	for _, file := range files {
+		if err := benchFile(fsys, file, snap); err != nil {
+			exit(1, err)
+		}
+	}
+
+	// Load the previous snapshot.
+	old := make(testfile.Snapshot)
+	content, err := os.ReadFile(*snapFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if *check {
+			exit(1, fmt.Errorf("snapshot file %s does not exist", *snapFile))
+		}
+	case err != nil:
+		exit(1, err)
+	default:
+		if old, err = testfile.ReadSnapshot(bytes.NewReader(content)); err != nil {
+			exit(1, fmt.Errorf("%s: %v", *snapFile, err))
+		}
+	}
+
+	// Show changes.
+	var regressions int
+	for _, c := range snap.Compare(old) {
+		switch {
+		case c.Added:
+			fmt.Printf("new      %s (gas: %d)\n", c.Name, c.New)
+		case c.Removed:
+			fmt.Printf("removed  %s (gas: %d)\n", c.Name, c.Old)
+		default:
+			status := "improved"
+			if c.New > c.Old {
+				status = "worse"
+				if c.Percent() > *tolerance {
+					status = "REGRESS"
+					regressions++
+				}
+			}
+			fmt.Printf("%-8s %s (gas: %d -> %d, %+.2f%%)\n", status, c.Name, c.Old, c.New, c.Percent())
+		}
+	}
+
+	if *check {
+		if regressions > 0 {
+			exit(1, fmt.Errorf("gas usage regressed in %d tests", regressions))
+		}
+		return
+	}
+	out, err := os.Create(*snapFile)
+	if err != nil {
+		exit(1, err)
+	}
+	_, err = snap.WriteTo(out)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		exit(1, err)
+	}
+}
+
+// benchFile runs the cases of a test file and records their gas usage.
+func benchFile(fsys fs.FS, file string, snap testfile.Snapshot) error {
+	tf, err := testfile.Load(fsys, file)
+	if err != nil {
+		return err
+	}
+	results, err := tf.Run(fsys)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			fmt.Fprintf(os.Stderr, "warning: %s:%d: test %s fails\n", file, r.Case.Line, r.Case.Name)
+		}
+		snap[testfile.SnapshotKey(file, r.Case)] = r.Exec.GasUsed
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/disasm"
+)
+
+func cfgExporter(args []string) {
+	var (
+		fs         = newFlagSet("-cfg")
+		outputFile = fs.String("o", "-", "")
+		jsonOutput = fs.Bool("json", false, "")
+		hexInput   = fs.Bool("hex", false, "")
+		binary     = fs.Bool("bin", false, "")
+		target     = fs.String("target", "", "")
+		data       = fs.Bool("data", false, "")
+		sigsFile   = fs.String("sigs", "", "")
+		stdinName  = fs.String("stdin-name", "", "")
+	)
+	parseFlags(fs, args)
+
+	d := disasm.New()
+	d.SetDetectData(*data)
+	if *sigsFile != "" {
+		sigs := disasm.NewSignatures()
+		if err := sigs.LoadFile(*sigsFile); err != nil {
+			exit(1, err)
+		}
+		d.SetSignatures(sigs)
+	}
+
+	// Get the bytecode. Source programs are assembled, and their symbol table
+	// provides the label names and #bytes regions.
+	var bytecode []byte
+	if *hexInput || *binary {
+		bytecode = readBytecode(fileArg(fs), *binary)
+	} else {
+		c := asm.New(nil)
+		bytecode = compileInput(c, fileArg(fs), *stdinName)
+		syms := c.Symbols()
+		d.SetSymbols(syms)
+		if *target == "" {
+			*target = syms.Fork
+		}
+	}
+	if *target != "" {
+		if err := d.SetTarget(*target); err != nil {
+			exit(2, err)
+		}
+	}
+
+	g := d.ControlFlowGraph(bytecode)
+	err := writeOutput(*outputFile, func(w io.Writer) error {
+		if !*jsonOutput {
+			return g.WriteDOT(w)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	})
+	if err != nil {
+		exit(1, err)
+	}
+}
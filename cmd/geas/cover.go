@@ -0,0 +1,95 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fjl/geas/internal/coverage"
+)
+
+// coverFlags are the coverage report options of -r and -test.
+type coverFlags struct {
+	lcov string
+	html string
+	text string
+}
+
+func (cf *coverFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&cf.lcov, "coverprofile", "", "")
+	fs.StringVar(&cf.html, "coverhtml", "", "")
+	fs.StringVar(&cf.text, "covertext", "", "")
+}
+
+// enabled reports whether any coverage output was requested.
+func (cf *coverFlags) enabled() bool {
+	return cf.lcov != "" || cf.html != "" || cf.text != ""
+}
+
+// write creates the requested coverage reports. The sources may be nil.
+func (cf *coverFlags) write(report *coverage.Report, sources *sourceCache) {
+	var source coverage.SourceFunc
+	if sources != nil {
+		source = sources.lines
+	}
+	outputs := []struct {
+		file  string
+		write func(io.Writer) error
+	}{
+		{cf.lcov, report.WriteLCOV},
+		{cf.html, func(w io.Writer) error { return report.WriteHTML(w, source) }},
+		{cf.text, func(w io.Writer) error { return report.WriteText(w, source) }},
+	}
+	for _, out := range outputs {
+		if out.file == "" {
+			continue
+		}
+		if err := writeOutput(out.file, out.write); err != nil {
+			exit(1, err)
+		}
+	}
+
+	s := report.Summary()
+	fmt.Fprintf(os.Stderr, "coverage: %s of lines, %s of branches\n",
+		coverPercent(s.LinesHit, s.Lines), coverPercent(s.BranchesHit, s.Branches))
+}
+
+// writeOutput calls write with the named file, or stdout if the name is "-".
+func writeOutput(file string, write func(io.Writer) error) error {
+	if file == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func coverPercent(hit, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(hit)/float64(total)*100)
+}
@@ -0,0 +1,346 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/debugger"
+)
+
+func debugRunner(args []string) {
+	var (
+		fs = newFlagSet("-debug")
+		rf runFlags
+	)
+	rf.register(fs)
+	parseFlags(fs, args)
+
+	file := fileArg(fs)
+	if file == "-" || file == "/dev/stdin" {
+		exit(2, fmt.Errorf("can't debug program from stdin, commands are read from stdin"))
+	}
+	c := asm.New(nil)
+	c.SetStackCheck(true)
+	if rf.target != "" {
+		c.SetDefaultFork(rf.target)
+	}
+	bin := compileInput(c, file, "")
+	info := c.DebugInfo()
+	cfg, err := rf.config(info)
+	if err != nil {
+		exit(2, err)
+	}
+	fsys, path := openSourceRoot(file)
+
+	d := &debugREPL{
+		s:       debugger.Start(bin, info, path, cfg),
+		code:    bin,
+		sources: newSourceCache(fsys),
+		out:     os.Stdout,
+	}
+	d.run(os.Stdin)
+}
+
+const debugHelp = `Commands:
+  s, step [n]         execute n instructions (default 1)
+  n, next             execute until the source line changes
+  c, continue         execute until a breakpoint is reached
+  b, break <loc>      set breakpoint at label, line or file:line
+  bl, breakpoints     list breakpoints
+  d, delete <id>      delete breakpoint
+  st, stack           show the stack
+  m, memory           show memory
+  sto, storage        show storage
+  w, where            show location and macro expansion chain
+  l, list             show source code around the current line
+  q, quit             exit the debugger
+
+An empty line repeats the previous command.
+`
+
+type debugREPL struct {
+	s       *debugger.Session
+	code    []byte
+	sources *sourceCache
+	out     io.Writer
+}
+
+func (d *debugREPL) run(in io.Reader) {
+	defer d.s.Close()
+
+	d.showLocation()
+	var (
+		scanner = bufio.NewScanner(in)
+		last    string
+	)
+	for {
+		fmt.Fprint(d.out, "(geas) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(d.out)
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			line = last
+		}
+		last = line
+		if line == "" {
+			continue
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		if !d.command(cmd, strings.TrimSpace(arg)) {
+			return
+		}
+	}
+}
+
+// command executes a debugger command. It returns false when the debugger should exit.
+func (d *debugREPL) command(cmd, arg string) bool {
+	switch cmd {
+	case "s", "step":
+		n := 1
+		if arg != "" {
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil || n < 1 {
+				fmt.Fprintf(d.out, "invalid step count %q\n", arg)
+				return true
+			}
+		}
+		if !d.running() {
+			return true
+		}
+		for range n {
+			d.s.Step()
+		}
+		d.showLocation()
+	case "n", "next":
+		if d.running() {
+			d.showBreakpoint(d.s.Next())
+			d.showLocation()
+		}
+	case "c", "continue":
+		if d.running() {
+			d.showBreakpoint(d.s.Continue())
+			d.showLocation()
+		}
+	case "b", "break":
+		bp, err := d.s.AddBreakpoint(arg)
+		if err != nil {
+			fmt.Fprintln(d.out, err)
+		} else {
+			fmt.Fprintf(d.out, "breakpoint %d at %s (pc %s)\n", bp.ID, bp.Spec, formatPCs(bp.PCs))
+		}
+	case "bl", "breakpoints":
+		for _, bp := range d.s.Breakpoints() {
+			fmt.Fprintf(d.out, "%d: %s (pc %s)\n", bp.ID, bp.Spec, formatPCs(bp.PCs))
+		}
+	case "d", "delete":
+		id, err := strconv.Atoi(arg)
+		if err == nil {
+			err = d.s.RemoveBreakpoint(id)
+		}
+		if err != nil {
+			fmt.Fprintln(d.out, err)
+		}
+	case "st", "stack":
+		if d.running() {
+			d.showStack()
+		}
+	case "m", "memory", "mem":
+		if d.running() {
+			d.showMemory()
+		}
+	case "sto", "storage":
+		d.showStorage()
+	case "w", "where":
+		if d.running() {
+			d.showWhere()
+		}
+	case "l", "list":
+		if d.running() {
+			d.showSource(5)
+		}
+	case "q", "quit":
+		return false
+	case "h", "help":
+		fmt.Fprint(d.out, debugHelp)
+	default:
+		fmt.Fprintf(d.out, "unknown command %q, type help to see all commands\n", cmd)
+	}
+	return true
+}
+
+// running checks whether the program is still executing.
+func (d *debugREPL) running() bool {
+	if d.s.Current() == nil {
+		fmt.Fprintln(d.out, "program has ended")
+		return false
+	}
+	return true
+}
+
+func (d *debugREPL) showBreakpoint(bp *debugger.Breakpoint) {
+	if bp != nil {
+		fmt.Fprintf(d.out, "breakpoint %d: %s\n", bp.ID, bp.Spec)
+	}
+}
+
+// showLocation prints the current instruction and stack, or the result
+// if execution has ended.
+func (d *debugREPL) showLocation() {
+	f := d.s.Current()
+	if f == nil {
+		res, err := d.s.Result()
+		if err != nil {
+			fmt.Fprintln(d.out, "error:", err)
+			return
+		}
+		fmt.Fprintln(d.out, "program ended")
+		printResult(d.out, res, d.s.Info())
+		return
+	}
+	fmt.Fprintf(d.out, "pc %d: %s", f.PC, d.formatInstruction(f))
+	if f.Instr != nil {
+		fmt.Fprintf(d.out, "  at %v", f.Instr.Pos)
+	}
+	fmt.Fprintf(d.out, "  (gas %d)\n", f.Gas)
+	d.showSource(0)
+	d.showStack()
+}
+
+// formatInstruction returns the opcode and immediate argument at the current PC.
+func (d *debugREPL) formatInstruction(f *debugger.Frame) string {
+	if f.Op.IsPush() && f.Op != vm.PUSH0 {
+		start := f.PC + 1
+		end := min(start+uint64(f.Op-vm.PUSH0), uint64(len(d.code)))
+		if start < end {
+			return fmt.Sprintf("%v 0x%x", f.Op, d.code[start:end])
+		}
+	}
+	return f.Op.String()
+}
+
+func (d *debugREPL) showStack() {
+	f := d.s.Current()
+	if len(f.Stack) == 0 {
+		fmt.Fprintln(d.out, "  stack: []")
+		return
+	}
+	fmt.Fprintln(d.out, "  stack:")
+	for i, v := range f.Stack {
+		name := ""
+		if i < len(f.StackNames) {
+			name = f.StackNames[i]
+		}
+		fmt.Fprintf(d.out, "  %4d  %-66s %s\n", i, v.Hex(), name)
+	}
+}
+
+func (d *debugREPL) showMemory() {
+	mem := d.s.Current().Memory
+	if len(mem) == 0 {
+		fmt.Fprintln(d.out, "memory is empty")
+		return
+	}
+	for offset := 0; offset < len(mem); offset += 32 {
+		end := min(offset+32, len(mem))
+		fmt.Fprintf(d.out, "  %#06x  %x\n", offset, mem[offset:end])
+	}
+}
+
+func (d *debugREPL) showStorage() {
+	slots := d.s.Storage()
+	if len(slots) == 0 {
+		fmt.Fprintln(d.out, "no storage accessed")
+		return
+	}
+	for _, s := range slots {
+		fmt.Fprintf(d.out, "  %v: %v\n", s.Key, s.Value)
+	}
+}
+
+func (d *debugREPL) showWhere() {
+	di := d.s.Current().Instr
+	if di == nil {
+		fmt.Fprintln(d.out, "pc is outside of program")
+		return
+	}
+	fmt.Fprintf(d.out, "  %s at %v\n", di.Op, di.Pos)
+	for _, frame := range di.Expansion {
+		if frame.Macro != "" {
+			fmt.Fprintf(d.out, "  in macro %%%s called at %v\n", frame.Macro, frame.Pos)
+		} else {
+			fmt.Fprintf(d.out, "  in %s included at %v\n", frame.Include, frame.Pos)
+		}
+	}
+}
+
+// showSource prints the current source line, and n lines of context
+// before and after it.
+func (d *debugREPL) showSource(n int) {
+	di := d.s.Current().Instr
+	if di == nil {
+		return
+	}
+	lines := d.sources.lines(di.Pos.File)
+	for l := max(di.Pos.Line-n, 1); l <= di.Pos.Line+n && l <= len(lines); l++ {
+		marker := " "
+		if l == di.Pos.Line {
+			marker = ">"
+		}
+		fmt.Fprintf(d.out, "%s %4d | %s\n", marker, l, t2s.Replace(lines[l-1]))
+	}
+}
+
+func formatPCs(pcs []int) string {
+	s := make([]string, len(pcs))
+	for i, pc := range pcs {
+		s[i] = strconv.Itoa(pc)
+	}
+	return strings.Join(s, ", ")
+}
+
+// sourceCache reads source files for display.
+type sourceCache struct {
+	fsys  fs.FS
+	files map[string][]string
+}
+
+func newSourceCache(fsys fs.FS) *sourceCache {
+	return &sourceCache{fsys: fsys, files: make(map[string][]string)}
+}
+
+func (sc *sourceCache) lines(file string) []string {
+	if l, ok := sc.files[file]; ok {
+		return l
+	}
+	var lines []string
+	if content, err := fs.ReadFile(sc.fsys, file); err == nil {
+		lines = strings.Split(string(content), "\n")
+	}
+	sc.files[file] = lines
+	return lines
+}
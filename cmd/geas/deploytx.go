@@ -0,0 +1,101 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/keyless"
+	"github.com/fjl/geas/internal/testfile"
+)
+
+func deployTxBuilder(args []string) {
+	var (
+		fs        = newFlagSet("-deploytx")
+		gasPrice  = fs.String("gasprice", "100000000000", "")
+		gasLimit  = fs.Uint64("gas", 0, "")
+		rFlag     = fs.String("r", "0x539", "")
+		sFlag     = fs.String("s", "0x539", "")
+		target    = fs.String("target", "", "")
+		outFile   = fs.String("o", "", "")
+		stdinName = fs.String("stdin-name", "", "")
+	)
+	parseFlags(fs, args)
+
+	c := asm.New(nil)
+	if *target != "" {
+		c.SetDefaultFork(*target)
+	}
+	initcode := compileInput(c, fileArg(fs), *stdinName)
+	estimate, err := keyless.EstimateGas(initcode, c.DebugInfo().Fork)
+	if err != nil {
+		exit(1, err)
+	}
+
+	cfg := keyless.Config{
+		Initcode: initcode,
+		Gas:      *gasLimit,
+		GasPrice: parseNumberFlag("gasprice", *gasPrice),
+		R:        parseNumberFlag("r", *rFlag),
+		S:        parseNumberFlag("s", *sFlag),
+	}
+	if cfg.Gas == 0 {
+		// Leave some room for future gas cost increases.
+		cfg.Gas = estimate * 5 / 4
+	} else if cfg.Gas < estimate {
+		exit(1, fmt.Errorf("gas limit %d is below the estimated gas %d", cfg.Gas, estimate))
+	}
+	d, err := keyless.Build(cfg)
+	if err != nil {
+		exit(1, err)
+	}
+	rawtx, err := d.Tx.MarshalBinary()
+	if err != nil {
+		exit(1, err)
+	}
+
+	v, r, s := d.Tx.RawSignatureValues()
+	fmt.Printf("sender:    %v\n", d.Sender)
+	fmt.Printf("contract:  %v\n", d.Contract)
+	fmt.Printf("gas limit: %d (estimated %d)\n", d.Tx.Gas(), estimate)
+	fmt.Printf("cost:      %v wei\n", d.Cost())
+	fmt.Printf("signature: v=%v r=%#x s=%#x", v, r, s)
+	if d.Attempts > 1 {
+		fmt.Printf(" (found after %d attempts)", d.Attempts)
+	}
+	fmt.Println()
+	if *outFile != "" {
+		if err := os.WriteFile(*outFile, []byte(hexutil.Encode(rawtx)+"\n"), 0644); err != nil {
+			exit(1, err)
+		}
+		return
+	}
+	fmt.Printf("rawtx:     %s\n", hexutil.Encode(rawtx))
+}
+
+// parseNumberFlag parses a decimal or hex number flag value.
+func parseNumberFlag(name, value string) *big.Int {
+	v, err := testfile.ParseWord(value)
+	if err != nil {
+		exit(2, fmt.Errorf("invalid -%s: %v", name, err))
+	}
+	return v.Big()
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/fuzz"
+	"github.com/fjl/geas/internal/testfile"
+)
+
+func fuzzer(args []string) {
+	var (
+		fs        = newFlagSet("-fuzz")
+		duration  = fs.Duration("time", 10*time.Second, "")
+		runs      = fs.Int("runs", 0, "")
+		seed      = fs.Uint64("seed", 0, "")
+		invariant = fs.String("invariant", "", "")
+		outFile   = fs.String("o", "", "")
+		rf        runFlags
+	)
+	rf.register(fs)
+	parseFlags(fs, args)
+
+	file := fileArg(fs)
+	if file == "-" || file == "/dev/stdin" {
+		exit(2, fmt.Errorf("can't fuzz program from stdin"))
+	}
+	c := asm.New(nil)
+	if rf.target != "" {
+		c.SetDefaultFork(rf.target)
+	}
+	bin := compileInput(c, file, "")
+	info := c.DebugInfo()
+	cfg, err := rf.config(info)
+	if err != nil {
+		exit(2, err)
+	}
+	fsys, srcPath := openSourceRoot(file)
+
+	fc := fuzz.Config{Code: bin, Info: info, Call: cfg, Seed: *seed}
+	var invPath string
+	if *invariant != "" {
+		if invPath, err = convertToRelativePath(*invariant); err != nil {
+			exit(2, err)
+		}
+		if fc.Invariant, err = testfile.CompileInvariant(fsys, invPath, info.Fork); err != nil {
+			exit(2, err)
+		}
+	}
+	if fc.Seed == 0 {
+		fc.Seed = rand.Uint64()
+	}
+	fmt.Fprintf(os.Stderr, "fuzzing %s (seed %d)\n", srcPath, fc.Seed)
+
+	// Run the fuzzer.
+	var (
+		f        = fuzz.New(fc)
+		deadline = time.Now().Add(*duration)
+	)
+	for {
+		if *runs > 0 && f.Execs >= *runs || *runs == 0 && time.Now().After(deadline) {
+			break
+		}
+		crash, err := f.Step()
+		if err != nil {
+			exit(1, err)
+		}
+		if crash != nil {
+			printCrash(crash)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d runs, corpus size %d, coverage %d\n", f.Execs, f.CorpusSize(), f.Coverage())
+
+	crashes := f.Crashes()
+	if len(crashes) == 0 {
+		fmt.Println("no failures found")
+		return
+	}
+
+	// Save the crashes as a test file.
+	out := *outFile
+	if out == "" {
+		out = strings.TrimSuffix(srcPath, path.Ext(srcPath)) + "_fuzz" + testfile.Suffix
+	}
+	if out, err = convertToRelativePath(out); err != nil {
+		exit(2, err)
+	}
+	tf, err := crashTestFile(fsys, out, srcPath, invPath, &rf)
+	if err != nil {
+		exit(1, err)
+	}
+	added := addCrashCases(tf, crashes, &rf)
+	if err := writeTestFile(out, tf); err != nil {
+		exit(1, err)
+	}
+	fmt.Printf("%d failures found, %d test cases added to %s\n", len(crashes), added, out)
+	os.Exit(1)
+}
+
+func printCrash(c *fuzz.Crash) {
+	fmt.Printf("%s: %v\n", crashLocation(c), c.Err)
+	fmt.Printf("  input:  0x%x\n", c.Input.Data)
+	if c.Input.Value != nil {
+		fmt.Printf("  value:  %v\n", c.Input.Value)
+	}
+	if c.Input.Caller != evmrun.DefaultCaller {
+		fmt.Printf("  caller: %v\n", c.Input.Caller)
+	}
+}
+
+// crashLocation describes where a crash happened.
+func crashLocation(c *fuzz.Crash) string {
+	if c.Instr == nil {
+		return fmt.Sprintf("%v at pc %d", c.Kind, c.PC)
+	}
+	loc := fmt.Sprintf("%v at %v (pc %d)", c.Kind, c.Instr.Pos, c.PC)
+	for _, frame := range c.Instr.Expansion {
+		if frame.Macro != "" {
+			loc += fmt.Sprintf(" in %%%s called at %v", frame.Macro, frame.Pos)
+			break
+		}
+	}
+	return loc
+}
+
+// crashTestFile loads the test file for crashes, or creates it if it does not exist.
+func crashTestFile(fsys fs.FS, out, contract, invariant string, rf *runFlags) (*testfile.File, error) {
+	tf, err := testfile.Load(fsys, out)
+	switch {
+	case err == nil:
+		return tf, nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	tf = &testfile.File{Path: out, Target: rf.target}
+	dir := path.Dir(out)
+	if tf.Contract, err = relPath(dir, contract); err != nil {
+		return nil, err
+	}
+	if invariant != "" {
+		if tf.Invariant, err = relPath(dir, invariant); err != nil {
+			return nil, err
+		}
+	}
+	return tf, nil
+}
+
+func relPath(dir, file string) (string, error) {
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(file))
+	return filepath.ToSlash(rel), err
+}
+
+// addCrashCases adds test cases reproducing the crashes. Crashes which are already in
+// the file are skipped. It returns the number of added cases.
+func addCrashCases(tf *testfile.File, crashes []*fuzz.Crash, rf *runFlags) int {
+	var added int
+	for _, c := range crashes {
+		name := fmt.Sprintf("%s-pc%d", strings.ReplaceAll(c.Kind.String(), " ", "-"), c.PC)
+		exists := false
+		for _, tc := range tf.Cases {
+			exists = exists || tc.Name == name
+		}
+		if exists {
+			continue
+		}
+		tc := &testfile.Case{
+			Name:    name,
+			Comment: fmt.Sprintf("%s\n%v", crashLocation(c), c.Err),
+			Call: testfile.Call{
+				Input:   fmt.Sprintf("0x%x", c.Input.Data),
+				Storage: rf.call.Storage,
+			},
+		}
+		if rf.call.Gas != evmrun.DefaultGasLimit {
+			tc.Gas = rf.call.Gas
+		}
+		if c.Input.Value != nil && c.Input.Value.Sign() > 0 {
+			tc.Value = c.Input.Value.String()
+		}
+		if c.Input.Caller != evmrun.DefaultCaller {
+			tc.Caller = c.Input.Caller.Hex()
+		}
+		tf.Cases = append(tf.Cases, tc)
+		added++
+	}
+	return added
+}
+
+func writeTestFile(file string, tf *testfile.File) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := tf.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
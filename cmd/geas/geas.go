@@ -19,9 +19,11 @@ package main
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime/debug"
@@ -42,7 +44,18 @@ func usage() {
 	if len(vsn) > 0 {
 		fmt.Fprintln(os.Stderr, "Version:", vsn)
 	}
-	fmt.Fprint(os.Stderr, `Usage: geas -[adfi] [options...] <file>`+
+	fmt.Fprint(os.Stderr, `Usage: geas -[adfri] [options...] <file>
+       geas -test [options...] [<file or directory>...]
+       geas -debug [options...] <file>
+       geas -profile [options...] <file>
+       geas -bench [options...] [<file or directory>...]
+       geas -fuzz [options...] <file>
+       geas -statetest [options...] <file>
+       geas -alloc [options...] <file>
+       geas -deploytx [options...] <file>
+       geas -mine-salt [options...] <file>
+       geas -trace-view -trace <file> [options...] <file>
+       geas -cfg [options...] <file>`+
 		t2s.Replace(`
  -a: ASSEMBLER (default)
 
@@ -51,6 +64,10 @@ func usage() {
 	 -no-nl             skip newline at end of hex output
 	 -no-stackcheck     disable stack checker
 	 -stackcheck        (legacy) enable stack checker
+	 -dce               remove unreachable code
+	 -layout            optimize code layout to reduce jumps
+	 -symbols <file>    write symbol table (JSON) for the disassembler
+	 -stdin-name <file> file name of source read from stdin
 
  -d: DISASSEMBLER
 
@@ -60,7 +77,13 @@ func usage() {
 	 -blocks            blank lines between logical blocks
 	 -pc                show program counter on all lines
 	 -pclabel           show program counter at jumpdest instructions
+	 -labels            recover jump targets as symbolic labels
+	 -data              show metadata, unreachable code and CODECOPY data as #bytes
+	 -stack             annotate instructions with stack comments
+	 -sigs <file>       signature database (text, JSON or ABI) for selectors and hashes
+	 -symbols <file>    restore labels and #bytes from symbol table created by -a
 	 -uppercase         show instruction names as uppercase
+	 -col <n>           align line comments to column n (0 = auto)
 
  -f: SOURCE FORMATTER
 
@@ -68,6 +91,114 @@ func usage() {
 	 -check             exit with error if file is not formatted
 	 -col <n>           align line comments to column n (0 = auto)
 
+ -r: RUN
+
+	 -input <hex>       calldata
+	 -value <n>         callvalue
+	 -caller <address>  caller address
+	 -gas <n>           gas limit
+	 -target <name>     default instruction set (overridden by #pragma target)
+	 -storage <k>=<v>   initial storage slot value, can be given multiple times
+	 -stdin-name <file> file name of source read from stdin
+	 -coverprofile <f>  write coverage in LCOV format
+	 -coverhtml <f>     write coverage as HTML
+	 -covertext <f>     write source listing with execution counts ("-" = stdout)
+
+ -test: TEST
+
+	 -v                 show passing tests
+	 -run <name>        only run tests whose name contains the given string
+	 -coverprofile <f>  write coverage in LCOV format
+	 -coverhtml <f>     write coverage as HTML
+	 -covertext <f>     write source listing with execution counts ("-" = stdout)
+
+	 Test files are named *_test.yaml. Directories are searched recursively.
+
+ -debug: DEBUGGER
+
+	 Accepts the execution options of -r.
+	 Type 'help' at the debugger prompt to see all commands.
+
+ -profile: GAS PROFILER
+
+	 Accepts the execution options of -r. Prints the source with gas used per line.
+
+	 -pprof <file>      write profile for 'go tool pprof'
+
+ -bench: GAS SNAPSHOT
+
+	 -snapshot <file>   snapshot file (default .gas-snapshot)
+	 -check             compare with snapshot instead of updating it
+	 -tolerance <pct>   allowed gas increase in percent for -check
+
+	 Records the gas used by all cases in test files (*_test.yaml).
+
+ -fuzz: FUZZER
+
+	 Accepts the execution options of -r. The input is used as the initial corpus.
+
+	 -time <duration>   how long to run (default 10s)
+	 -runs <n>          number of executions, overrides -time
+	 -seed <n>          random seed
+	 -invariant <file>  check contract, runs at the contract address after each call
+	 -o <file>          test file for failing inputs (default <file>_fuzz_test.yaml)
+
+ -statetest: STATE TEST GENERATOR
+
+	 -o <file>          output file name (default stdout)
+
+	 Creates a GeneralStateTest JSON file from a state test definition (*_statetest.yaml).
+
+ -alloc: GENESIS ALLOC GENERATOR
+
+	 -o <file>          output file name (default stdout)
+
+	 Creates a genesis alloc JSON object from a manifest of accounts.
+
+ -deploytx: KEYLESS DEPLOYMENT TRANSACTION
+
+	 -gasprice <n>      gas price in wei (default 100 gwei)
+	 -gas <n>           gas limit (default estimated gas + 25%)
+	 -r <n>             signature r value, incremented until valid (default 0x539)
+	 -s <n>             signature s value (default 0x539)
+	 -target <name>     default instruction set (overridden by #pragma target)
+	 -o <file>          write raw transaction to file
+	 -stdin-name <file> file name of source read from stdin
+
+	 The input file is the constructor of the contract.
+
+ -mine-salt: CREATE2 SALT SEARCH
+
+	 -prefix <hex>      address must start with these hex digits
+	 -suffix <hex>      address must end with these hex digits
+	 -deployer <addr>   CREATE2 deployer (default deterministic deployment proxy)
+	 -workers <n>       number of parallel workers (default number of CPUs)
+	 -time <duration>   give up after this long (default no limit)
+	 -target <name>     default instruction set (overridden by #pragma target)
+	 -o <file>          write salt as #define into include file
+	 -name <name>       name of the salt definition (default Salt)
+	 -stdin-name <file> file name of source read from stdin
+
+	 The input file is the constructor (initcode) of the contract.
+
+ -trace-view: TRACE VIEWER
+
+	 -trace <file>      struct log trace (debug_traceTransaction or evm --json)
+	 -depth <n>         call depth of the contract in the trace (default 1)
+	 -target <name>     default instruction set (overridden by #pragma target)
+	 -stack=false       do not show the stack
+
+ -cfg: CONTROL FLOW GRAPH
+
+	 -o <file>          output file name
+	 -json              output JSON instead of Graphviz DOT
+	 -hex               input is hex bytecode instead of source
+	 -bin               input is binary bytecode
+	 -target <name>     instruction set of bytecode input
+	 -data              skip metadata, unreachable code and CODECOPY data in bytecode
+	 -sigs <file>       signature database (text, JSON or ABI) for selectors and hashes
+	 -stdin-name <file> file name of source read from stdin
+
  -i: INFORMATION
 
 	 -targets           show supported target fork names
@@ -97,6 +228,42 @@ func main() {
 	case mode == "-f":
 		formatter(os.Args[2:])
 
+	case mode == "-r":
+		runner(os.Args[2:])
+
+	case mode == "-test":
+		tester(os.Args[2:])
+
+	case mode == "-debug":
+		debugRunner(os.Args[2:])
+
+	case mode == "-profile":
+		profiler(os.Args[2:])
+
+	case mode == "-fuzz":
+		fuzzer(os.Args[2:])
+
+	case mode == "-statetest":
+		stateTestGenerator(os.Args[2:])
+
+	case mode == "-alloc":
+		allocGenerator(os.Args[2:])
+
+	case mode == "-deploytx":
+		deployTxBuilder(os.Args[2:])
+
+	case mode == "-mine-salt":
+		saltMiner(os.Args[2:])
+
+	case mode == "-bench":
+		benchmarker(os.Args[2:])
+
+	case mode == "-trace-view":
+		traceViewer(os.Args[2:])
+
+	case mode == "-cfg":
+		cfgExporter(os.Args[2:])
+
 	case mode == "-i":
 		information(os.Args[2:])
 
@@ -117,6 +284,10 @@ func assembler(args []string) {
 		outputFile = fs.String("o", "", "")
 		binary     = fs.Bool("bin", false, "")
 		noNL       = fs.Bool("no-nl", false, "")
+		deadCode   = fs.Bool("dce", false, "")
+		layout     = fs.Bool("layout", false, "")
+		symFile    = fs.String("symbols", "", "")
+		stdinName  = fs.String("stdin-name", "", "")
 		stackcheck = true
 	)
 	fs.BoolFunc("stackcheck", "", func(value string) error {
@@ -132,37 +303,17 @@ func assembler(args []string) {
 	// Assemble.
 	c := asm.New(nil)
 	c.SetStackCheck(stackcheck)
-	var bin []byte
-	switch file := fileArg(fs); file {
-	case "-", "/dev/stdin":
-		source, err := io.ReadAll(io.LimitReader(os.Stdin, inputLimit))
-		if err != nil {
-			exit(2, err)
-		}
-		bin = c.CompileString(string(source))
-	default:
-		root, err := os.OpenRoot(".")
-		if err != nil {
-			exit(2, err)
-		}
-		path, err := convertToRelativePath(file)
-		if err != nil {
-			exit(2, err)
-		}
-		c.SetFilesystem(root.FS())
-		bin = c.CompileFile(path)
-	}
-
-	// Show errors.
-	for _, err := range c.ErrorsAndWarnings() {
-		fmt.Fprintln(os.Stderr, err)
-	}
-	if c.Failed() {
-		os.Exit(1)
-	}
+	c.SetDeadCodeElimination(*deadCode)
+	c.SetLayoutOptimization(*layout)
+	bin := compileInput(c, fileArg(fs), *stdinName)
 
 	// Write output.
 	var err error
+	if *symFile != "" {
+		if err := writeSymbols(*symFile, c.Symbols()); err != nil {
+			exit(1, err)
+		}
+	}
 	output := os.Stdout
 	if *outputFile != "" {
 		output, err = os.OpenFile(*outputFile, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
@@ -185,47 +336,87 @@ func assembler(args []string) {
 	}
 }
 
+// writeSymbols writes the symbol table of a compilation.
+func writeSymbols(file string, syms *asm.Symbols) error {
+	text, err := json.MarshalIndent(syms, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(text, '\n'), 0644)
+}
+
+// readSymbols reads a symbol table created by writeSymbols.
+func readSymbols(file string) (*asm.Symbols, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	syms := new(asm.Symbols)
+	if err := json.Unmarshal(text, syms); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return syms, nil
+}
+
+// compileInput compiles the given file, or standard input if file is "-".
+// Errors and warnings are printed, and the program exits if compilation fails.
+func compileInput(c *asm.Compiler, file string, stdinName string) []byte {
+	var bin []byte
+	switch file {
+	case "-", "/dev/stdin":
+		source, err := io.ReadAll(io.LimitReader(os.Stdin, inputLimit))
+		if err != nil {
+			exit(2, err)
+		}
+		if stdinName == "" {
+			bin = c.CompileString(string(source))
+			break
+		}
+		// Source text is given a file name, so includes resolve relative to it.
+		fsys, path := openSourceRoot(stdinName)
+		c.SetFilesystem(fsys)
+		bin = c.CompileSource(path, source)
+	default:
+		fsys, path := openSourceRoot(file)
+		c.SetFilesystem(fsys)
+		bin = c.CompileFile(path)
+	}
+
+	// Show errors.
+	for _, err := range c.ErrorsAndWarnings() {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if c.Failed() {
+		os.Exit(1)
+	}
+	return bin
+}
+
 func disassembler(args []string) {
 	var (
 		fs         = newFlagSet("-d")
 		outputFile = fs.String("o", "", "")
 		showPC     = fs.Bool("pc", false, "")
 		pcLabels   = fs.Bool("pclabel", false, "")
+		labels     = fs.Bool("labels", false, "")
+		data       = fs.Bool("data", false, "")
+		stack      = fs.Bool("stack", false, "")
+		sigsFile   = fs.String("sigs", "", "")
+		symFile    = fs.String("symbols", "", "")
 		showBlocks = fs.Bool("blocks", true, "")
 		uppercase  = fs.Bool("uppercase", false, "")
+		commentCol = fs.Int("col", 0, "")
 		binary     = fs.Bool("bin", false, "")
 		target     = fs.String("target", "", "")
 	)
 	parseFlags(fs, args)
-
-	// Read input.
-	var err error
-	var infd io.ReadCloser
-	switch file := fileArg(fs); file {
-	case "-", "/dev/stdin":
-		infd = os.Stdin
-	default:
-		infd, err = os.Open(file)
-		if err != nil {
-			exit(1, err)
-		}
-	}
-	bytecode, err := io.ReadAll(io.LimitReader(infd, inputLimit))
-	if err != nil {
-		exit(1, err)
+	if *commentCol < 0 {
+		exit(2, fmt.Errorf("comment column must not be negative"))
 	}
-	infd.Close()
 
-	// Possibly convert from hex.
-	if !*binary {
-		dec := make([]byte, hex.DecodedLen(len(bytecode)))
-		l, err := hex.Decode(dec, bytes.TrimSpace(bytecode))
-		if err != nil {
-			exit(1, err)
-		}
-		bytecode = dec[:l]
-	}
+	bytecode := readBytecode(fileArg(fs), *binary)
 
+	var err error
 	output := os.Stdout
 	if *outputFile != "" {
 		output, err = os.OpenFile(*outputFile, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
@@ -240,7 +431,31 @@ func disassembler(args []string) {
 	d.SetShowBlocks(*showBlocks)
 	d.SetShowPC(*showPC)
 	d.SetPCLabels(*pcLabels)
+	d.SetSymbolicLabels(*labels)
+	d.SetDetectData(*data)
+	d.SetStackComments(*stack)
+	if *sigsFile != "" {
+		sigs := disasm.NewSignatures()
+		if err := sigs.LoadFile(*sigsFile); err != nil {
+			exit(1, err)
+		}
+		d.SetSignatures(sigs)
+	}
+	if *symFile != "" {
+		syms, err := readSymbols(*symFile)
+		if err != nil {
+			exit(1, err)
+		}
+		d.SetSymbols(syms)
+		if *target == "" {
+			// Disassemble for the instruction set of the program.
+			*target = syms.Fork
+		}
+	}
 	d.SetUppercase(*uppercase)
+	if *commentCol > 0 {
+		d.SetCommentColumn(*commentCol)
+	}
 	if *target != "" {
 		if err := d.SetTarget(*target); err != nil {
 			exit(2, err)
@@ -250,6 +465,38 @@ func disassembler(args []string) {
 	exit(1, err)
 }
 
+// readBytecode reads bytecode from the given file, or standard input if file is "-".
+// Unless binary is set, the input is hex.
+func readBytecode(file string, binary bool) []byte {
+	var err error
+	var infd io.ReadCloser
+	switch file {
+	case "-", "/dev/stdin":
+		infd = os.Stdin
+	default:
+		infd, err = os.Open(file)
+		if err != nil {
+			exit(1, err)
+		}
+	}
+	bytecode, err := io.ReadAll(io.LimitReader(infd, inputLimit))
+	if err != nil {
+		exit(1, err)
+	}
+	infd.Close()
+
+	// Possibly convert from hex.
+	if !binary {
+		dec := make([]byte, hex.DecodedLen(len(bytecode)))
+		l, err := hex.Decode(dec, bytes.TrimSpace(bytecode))
+		if err != nil {
+			exit(1, err)
+		}
+		bytecode = dec[:l]
+	}
+	return bytecode
+}
+
 func formatter(args []string) {
 	var (
 		fs             = newFlagSet("-f")
@@ -513,6 +760,20 @@ func version() string {
 	return "git:" + gitVersion
 }
 
+// openSourceRoot opens the current directory as the file system for the assembler.
+// It also returns the path of file relative to the current directory.
+func openSourceRoot(file string) (fs.FS, string) {
+	root, err := os.OpenRoot(".")
+	if err != nil {
+		exit(2, err)
+	}
+	path, err := convertToRelativePath(file)
+	if err != nil {
+		exit(2, err)
+	}
+	return root.FS(), path
+}
+
 // convertToRelativePath makes a filepath relative to the current directory
 // and encodes it as a slash-delimited path.
 func convertToRelativePath(input string) (string, error) {
@@ -0,0 +1,111 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/salt"
+)
+
+// deterministicDeployer is the address of the CREATE2 proxy at
+// https://github.com/Arachnid/deterministic-deployment-proxy
+const deterministicDeployer = "0x4e59b44847b379578588920cA78FbF26c0B4956C"
+
+func saltMiner(args []string) {
+	var (
+		fs        = newFlagSet("-mine-salt")
+		deployer  = fs.String("deployer", deterministicDeployer, "")
+		prefix    = fs.String("prefix", "", "")
+		suffix    = fs.String("suffix", "", "")
+		workers   = fs.Int("workers", runtime.NumCPU(), "")
+		timeout   = fs.Duration("time", 0, "")
+		target    = fs.String("target", "", "")
+		outFile   = fs.String("o", "", "")
+		name      = fs.String("name", "Salt", "")
+		stdinName = fs.String("stdin-name", "", "")
+	)
+	parseFlags(fs, args)
+
+	if !common.IsHexAddress(*deployer) {
+		exit(2, fmt.Errorf("invalid -deployer address %q", *deployer))
+	}
+	pattern, err := salt.ParsePattern(*prefix, *suffix)
+	if err != nil {
+		exit(2, err)
+	}
+	if pattern.Prefix == "" && pattern.Suffix == "" {
+		exit(2, fmt.Errorf("need -prefix or -suffix"))
+	}
+
+	c := asm.New(nil)
+	if *target != "" {
+		c.SetDefaultFork(*target)
+	}
+	file := fileArg(fs)
+	initcode := compileInput(c, file, *stdinName)
+	initcodeHash := crypto.Keccak256Hash(initcode)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	// Start from a random salt so that repeated runs find different results.
+	var start common.Hash
+	rand.Read(start[:])
+
+	fmt.Fprintf(os.Stderr, "searching with %d workers, expecting ~%.0f tries\n", *workers, pattern.Difficulty())
+	begin := time.Now()
+	res, err := salt.Search(ctx, common.HexToAddress(*deployer), initcodeHash, pattern, start, *workers)
+	if err != nil {
+		exit(1, fmt.Errorf("no salt found: %v", err))
+	}
+	elapsed := time.Since(begin)
+
+	fmt.Printf("salt:     %v\n", res.Salt)
+	fmt.Printf("address:  %v\n", res.Address)
+	fmt.Printf("tries:    %d (%v, %.0f/s)\n", res.Tries, elapsed.Round(time.Millisecond), float64(res.Tries)/elapsed.Seconds())
+	if *outFile != "" {
+		if err := os.WriteFile(*outFile, []byte(saltInclude(*name, *deployer, file, res)), 0644); err != nil {
+			exit(1, err)
+		}
+	}
+}
+
+// saltInclude creates a geas include file defining the salt.
+func saltInclude(name, deployer, file string, res *salt.Result) string {
+	return fmt.Sprintf(`;;; Generated by geas -mine-salt. Do not edit.
+;;;
+;;; initcode: %s
+;;; deployer: %v
+;;; address:  %v
+
+#define %s = %v
+`, file, common.HexToAddress(deployer), res.Address, name, res.Salt)
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/gasprof"
+)
+
+func profiler(args []string) {
+	var (
+		fs        = newFlagSet("-profile")
+		pprofFile = fs.String("pprof", "", "")
+		stdinName = fs.String("stdin-name", "", "")
+		rf        runFlags
+	)
+	rf.register(fs)
+	parseFlags(fs, args)
+
+	file := fileArg(fs)
+	c := asm.New(nil)
+	c.SetStackCheck(true)
+	if rf.target != "" {
+		c.SetDefaultFork(rf.target)
+	}
+	bin := compileInput(c, file, *stdinName)
+	info := c.DebugInfo()
+	cfg, err := rf.config(info)
+	if err != nil {
+		exit(2, err)
+	}
+	prof := gasprof.New(info)
+	cfg.Tracer = prof.Hooks()
+	res, err := evmrun.Run(bin, cfg)
+	if err != nil {
+		exit(2, err)
+	}
+
+	// Source listing can only be shown when the program was read from a file.
+	var sources *sourceCache
+	if name := sourceName(file, *stdinName); name != "" {
+		fsys, _ := openSourceRoot(name)
+		sources = newSourceCache(fsys)
+	}
+	printProfile(os.Stdout, prof, sources)
+	if res.Err != nil {
+		fmt.Fprintf(os.Stdout, "\nexecution failed: %v\n", res.Err)
+	}
+
+	if *pprofFile != "" {
+		out, err := os.Create(*pprofFile)
+		if err != nil {
+			exit(1, err)
+		}
+		err = prof.WritePprof(out)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			exit(1, err)
+		}
+	}
+}
+
+// sourceName returns the name of the input file, or "" for unnamed standard input.
+func sourceName(file, stdinName string) string {
+	if file == "-" || file == "/dev/stdin" {
+		return stdinName
+	}
+	return file
+}
+
+// printProfile writes the annotated source listing of a gas profile.
+func printProfile(w io.Writer, prof *gasprof.Profile, sources *sourceCache) {
+	fmt.Fprintf(w, "total gas: %d\n", prof.Total)
+
+	lines := prof.Lines()
+	for i := 0; i < len(lines); {
+		file := lines[i].File
+		j := i
+		for j < len(lines) && lines[j].File == file {
+			j++
+		}
+		fmt.Fprintf(w, "\n%s:\n", file)
+		fmt.Fprintf(w, "%8s %8s %8s\n", "flat", "cum", "count")
+		printProfileLines(w, lines[i:j], sources)
+		i = j
+	}
+
+	if macros := prof.Macros(); len(macros) > 0 {
+		fmt.Fprintln(w, "\nmacros:")
+		fmt.Fprintf(w, "%8s %8s\n", "gas", "calls")
+		for _, m := range macros {
+			fmt.Fprintf(w, "%8d %8d  %%%s\n", m.Gas, m.Count, m.Name)
+		}
+	}
+	if includes := prof.Includes(); len(includes) > 0 {
+		fmt.Fprintln(w, "\nincludes:")
+		fmt.Fprintf(w, "%8s %8s\n", "gas", "count")
+		for _, inc := range includes {
+			fmt.Fprintf(w, "%8d %8d  %s\n", inc.Gas, inc.Count, inc.Name)
+		}
+	}
+}
+
+// printProfileLines writes the lines of a source file. If the file content
+// is not available, only lines with gas usage are shown.
+func printProfileLines(w io.Writer, lines []gasprof.LineSample, sources *sourceCache) {
+	var text []string
+	if sources != nil {
+		text = sources.lines(lines[0].File)
+	}
+	if len(text) == 0 {
+		for _, ls := range lines {
+			fmt.Fprintf(w, "%8d %8d %8d  line %d\n", ls.Flat.Gas, ls.Cum.Gas, ls.Cum.Count, ls.Line)
+		}
+		return
+	}
+	next := 0
+	for n, t := range text {
+		line := n + 1
+		if line == len(text) && t == "" {
+			break
+		}
+		if next < len(lines) && lines[next].Line == line {
+			ls := lines[next]
+			fmt.Fprintf(w, "%8d %8d %8d  %4d | %s\n", ls.Flat.Gas, ls.Cum.Gas, ls.Cum.Count, line, t2s.Replace(t))
+			next++
+		} else {
+			fmt.Fprintf(w, "%8s %8s %8s  %4d | %s\n", ".", ".", ".", line, t2s.Replace(t))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/coverage"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/testfile"
+)
+
+// runFlags are the execution options shared by all modes that run code.
+type runFlags struct {
+	call   testfile.Call
+	target string
+}
+
+func (rf *runFlags) register(fs *flag.FlagSet) {
+	rf.call.Storage = make(map[string]string)
+	fs.StringVar(&rf.call.Input, "input", "", "")
+	fs.StringVar(&rf.call.Value, "value", "", "")
+	fs.StringVar(&rf.call.Caller, "caller", "", "")
+	fs.Uint64Var(&rf.call.Gas, "gas", evmrun.DefaultGasLimit, "")
+	fs.StringVar(&rf.target, "target", "", "")
+	fs.Func("storage", "", func(v string) error {
+		slot, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("invalid storage %q, want slot=value", v)
+		}
+		rf.call.Storage[slot] = value
+		return nil
+	})
+}
+
+// config creates the execution config. The fork is taken from the compiler output.
+func (rf *runFlags) config(info *asm.DebugInfo) (evmrun.Config, error) {
+	return rf.call.Config(info.Fork)
+}
+
+func runner(args []string) {
+	var (
+		fs        = newFlagSet("-r")
+		stdinName = fs.String("stdin-name", "", "")
+		rf        runFlags
+		cf        coverFlags
+	)
+	rf.register(fs)
+	cf.register(fs)
+	parseFlags(fs, args)
+
+	file := fileArg(fs)
+	c := asm.New(nil)
+	c.SetStackCheck(true)
+	if rf.target != "" {
+		c.SetDefaultFork(rf.target)
+	}
+	bin := compileInput(c, file, *stdinName)
+	info := c.DebugInfo()
+	cfg, err := rf.config(info)
+	if err != nil {
+		exit(2, err)
+	}
+	var cov *coverage.Profile
+	if cf.enabled() {
+		cov = coverage.New(info)
+		cfg.Tracer = cov.Hooks()
+	}
+	res, err := evmrun.Run(bin, cfg)
+	if err != nil {
+		exit(2, err)
+	}
+	printResult(os.Stdout, res, info)
+	if cov != nil {
+		report := coverage.NewReport()
+		report.Add(cov)
+		var sources *sourceCache
+		if name := sourceName(file, *stdinName); name != "" {
+			fsys, _ := openSourceRoot(name)
+			sources = newSourceCache(fsys)
+		}
+		cf.write(report, sources)
+	}
+	if res.Err != nil {
+		os.Exit(1)
+	}
+}
+
+// printResult writes a summary of an execution.
+func printResult(w io.Writer, res *evmrun.Result, info *asm.DebugInfo) {
+	fmt.Fprintf(w, "return:   0x%x\n", res.ReturnData)
+	switch {
+	case res.Reverted() && res.RevertReason != "":
+		fmt.Fprintf(w, "revert:   %s\n", res.RevertReason)
+	case res.Reverted():
+		fmt.Fprintf(w, "revert:   (no reason)\n")
+	case res.Err != nil:
+		fmt.Fprintf(w, "error:    %v\n", res.Err)
+	}
+	fmt.Fprintf(w, "gas used: %d\n", res.GasUsed)
+	if di := info.InstructionAt(int(res.LastPC)); di != nil {
+		fmt.Fprintf(w, "end:      %s at %v (pc %d)\n", res.LastOp, di.Pos, res.LastPC)
+	}
+
+	if len(res.Logs) > 0 {
+		fmt.Fprintln(w, "logs:")
+		for i, l := range res.Logs {
+			fmt.Fprintf(w, "  [%d] %v\n", i, l.Address)
+			for _, t := range l.Topics {
+				fmt.Fprintf(w, "      topic %v\n", t)
+			}
+			fmt.Fprintf(w, "      data  0x%x\n", l.Data)
+		}
+	}
+	if len(res.Storage) > 0 {
+		fmt.Fprintln(w, "storage:")
+		for _, s := range res.Storage {
+			fmt.Fprintf(w, "  %v %v: %v -> %v\n", s.Address, s.Slot, s.Before, s.After)
+		}
+	}
+}
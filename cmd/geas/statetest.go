@@ -0,0 +1,68 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/fjl/geas/internal/statetest"
+)
+
+func stateTestGenerator(args []string) {
+	var (
+		fs      = newFlagSet("-statetest")
+		outFile = fs.String("o", "", "")
+	)
+	parseFlags(fs, args)
+
+	fsys, file := openSourceRoot(fileArg(fs))
+	spec, err := statetest.Load(fsys, file)
+	if err != nil {
+		exit(2, err)
+	}
+	filled, err := spec.Fill(fsys)
+	if err != nil {
+		exit(1, err)
+	}
+	enc, err := json.MarshalIndent(filled, "", "  ")
+	if err != nil {
+		exit(1, err)
+	}
+	enc = append(enc, '\n')
+
+	if *outFile == "" {
+		os.Stdout.Write(enc)
+	} else if err := os.WriteFile(*outFile, enc, 0644); err != nil {
+		exit(1, err)
+	}
+	for name, test := range filled {
+		for _, fork := range slices.Sorted(maps.Keys(test.Post)) {
+			printPostStates(os.Stderr, name, fork, test.Post[fork])
+		}
+	}
+}
+
+func printPostStates(w io.Writer, name, fork string, post []statetest.PostState) {
+	for _, ps := range post {
+		fmt.Fprintf(w, "%s %s d%d g%d v%d: root %v\n", name, fork, ps.Indexes.Data, ps.Indexes.Gas, ps.Indexes.Value, ps.Hash)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/fjl/geas/internal/coverage"
+	"github.com/fjl/geas/internal/testfile"
+)
+
+func tester(args []string) {
+	var (
+		fs      = newFlagSet("-test")
+		verbose = fs.Bool("v", false, "")
+		run     = fs.String("run", "", "")
+		cf      coverFlags
+	)
+	cf.register(fs)
+	parseFlags(fs, args)
+
+	var (
+		fsys, files   = discoverTestFiles(fs.Args())
+		report        = coverage.NewReport()
+		total, failed int
+	)
+	for _, file := range files {
+		tf, err := testfile.Load(fsys, file)
+		if err != nil {
+			exit(2, err)
+		}
+		code, info, err := tf.Compile(fsys)
+		var invariant []byte
+		if err == nil {
+			invariant, err = tf.CompileInvariant(fsys, info.Fork)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "FAIL %s: contract does not compile\n", file)
+			fmt.Fprintln(os.Stdout, indent(err.Error()))
+			failed++
+			continue
+		}
+		var (
+			cov    = coverage.New(info)
+			tracer *tracing.Hooks
+		)
+		if cf.enabled() {
+			tracer = cov.Hooks()
+		}
+		for _, tc := range tf.Cases {
+			if *run != "" && !strings.Contains(tc.Name, *run) {
+				continue
+			}
+			r, err := tc.Run(code, info, tracer)
+			if err != nil {
+				exit(2, fmt.Errorf("%s:%d: %s: %v", file, tc.Line, tc.Name, err))
+			}
+			r.CheckInvariant(invariant, info.Fork)
+			total++
+			if !r.Passed() {
+				failed++
+			}
+			if *verbose || !r.Passed() {
+				printTestResult(os.Stdout, file, r)
+			}
+		}
+		report.Add(cov)
+	}
+	if cf.enabled() {
+		cf.write(report, newSourceCache(fsys))
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stdout, "FAIL: %d of %d tests failed\n", failed, total)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stdout, "ok: %d tests passed\n", total)
+}
+
+// discoverTestFiles finds the test files in the given paths. If no paths are given,
+// the current directory is searched.
+func discoverTestFiles(paths []string) (fs.FS, []string) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	fsys, _ := openSourceRoot(".")
+	var files []string
+	for _, p := range paths {
+		path, err := convertToRelativePath(p)
+		if err != nil {
+			exit(2, err)
+		}
+		found, err := findTestFiles(fsys, path)
+		if err != nil {
+			exit(2, err)
+		}
+		files = append(files, found...)
+	}
+	if len(files) == 0 {
+		exit(2, fmt.Errorf("no test files found"))
+	}
+	return fsys, files
+}
+
+// findTestFiles returns the test files at path. If path is a directory,
+// it is searched recursively.
+func findTestFiles(fsys fs.FS, path string) ([]string, error) {
+	stat, err := fs.Stat(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = fs.WalkDir(fsys, path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != path && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(p, testfile.Suffix) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// printTestResult writes the outcome of a test case.
+func printTestResult(w io.Writer, file string, r *testfile.Result) {
+	status := "ok  "
+	if !r.Passed() {
+		status = "FAIL"
+	}
+	fmt.Fprintf(w, "%s %s:%d: %s (gas %d)\n", status, file, r.Case.Line, r.Case.Name, r.Exec.GasUsed)
+	for _, f := range r.Failures {
+		fmt.Fprintf(w, "     %s\n", f)
+	}
+	if !r.Passed() && r.End != nil {
+		fmt.Fprintf(w, "     execution ended at %s (%v)\n", r.Exec.LastOp, r.End.Pos)
+	}
+}
+
+func indent(s string) string {
+	return "     " + strings.ReplaceAll(s, "\n", "\n     ")
+}
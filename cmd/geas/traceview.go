@@ -0,0 +1,125 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/debugger"
+	"github.com/fjl/geas/internal/structlog"
+	"github.com/holiman/uint256"
+)
+
+func traceViewer(args []string) {
+	var (
+		fs        = newFlagSet("-trace-view")
+		traceFile = fs.String("trace", "", "")
+		depth     = fs.Int("depth", 1, "")
+		target    = fs.String("target", "", "")
+		showStack = fs.Bool("stack", true, "")
+	)
+	parseFlags(fs, args)
+	if *traceFile == "" {
+		exit(2, fmt.Errorf("-trace is required"))
+	}
+
+	c := asm.New(nil)
+	if *target != "" {
+		c.SetDefaultFork(*target)
+	}
+	bin := compileInput(c, fileArg(fs), "")
+	info := c.DebugInfo()
+
+	f, err := os.Open(*traceFile)
+	if err != nil {
+		exit(1, err)
+	}
+	steps, err := structlog.Read(f)
+	f.Close()
+	if err != nil {
+		exit(1, fmt.Errorf("%s: %v", *traceFile, err))
+	}
+	printTrace(os.Stdout, steps, *depth, bin, info, *showStack)
+}
+
+// printTrace writes the steps of the given call depth, annotated with source locations.
+func printTrace(w io.Writer, steps []structlog.Step, depth int, code []byte, info *asm.DebugInfo, showStack bool) {
+	var (
+		prev       *asm.DebugInstruction // instruction of the previous step
+		mismatches int
+		shown      int
+	)
+	for i, step := range steps {
+		if step.Depth != depth {
+			if step.Depth < depth {
+				prev = nil // new call frame
+			}
+			continue
+		}
+		shown++
+		di := info.InstructionAt(int(step.PC))
+		if step.PC >= uint64(len(code)) || vm.OpCode(code[step.PC]) != step.Op {
+			mismatches++
+		}
+
+		fmt.Fprintf(w, "%6d  pc %-5d %-14s gas %-9d", i, step.PC, step.Op, step.Gas)
+		if di != nil {
+			fmt.Fprintf(w, " %v", di.Pos)
+			for _, f := range di.Expansion {
+				if f.Macro != "" {
+					fmt.Fprintf(w, " < %%%s", f.Macro)
+				} else {
+					fmt.Fprintf(w, " < %s", f.Include)
+				}
+			}
+		}
+		fmt.Fprintln(w)
+		if showStack && len(step.Stack) > 0 {
+			fmt.Fprintf(w, "        stack: %s\n", formatNamedStack(step.Stack, debugger.StackNames(prev, di)))
+		}
+		if step.Error != "" {
+			fmt.Fprintf(w, "        error: %s\n", step.Error)
+		}
+		prev = di
+	}
+
+	if shown == 0 {
+		fmt.Fprintf(w, "no steps at depth %d\n", depth)
+	}
+	if mismatches > 0 {
+		fmt.Fprintf(w, "warning: %d steps do not match the compiled program, is this the right contract and depth?\n", mismatches)
+	}
+}
+
+// formatNamedStack formats stack items, labeling them with the given names.
+func formatNamedStack(stack []uint256.Int, names []string) string {
+	items := make([]string, len(stack))
+	for i := range stack {
+		v := stack[i].Hex()
+		if i < len(names) {
+			items[i] = names[i] + "=" + v
+		} else {
+			items[i] = v
+		}
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fjl/geas/internal/ast"
+	"github.com/fjl/geas/internal/printer"
+	"github.com/fjl/geas/internal/stack"
+)
+
+// CFG is the control flow graph of a program.
+type CFG struct {
+	Blocks []*CFGBlock `json:"blocks"`
+	Edges  []CFGEdge   `json:"edges"`
+}
+
+// CFGBlock is a basic block of the control flow graph.
+type CFGBlock struct {
+	ID           int              `json:"id"`
+	PC           int              `json:"pc"`
+	Label        string           `json:"label,omitempty"` // name of the jump target
+	StackIn      StackDepth       `json:"stackIn"`         // stack depth on entry
+	Instructions []CFGInstruction `json:"instructions"`
+}
+
+// CFGInstruction is an instruction in a basic block.
+type CFGInstruction struct {
+	PC    int        `json:"pc"`
+	Text  string     `json:"text"`
+	Stack StackDepth `json:"stack"` // stack depth after the instruction
+}
+
+// StackDepth is the number of items on the stack. When Open is set, the stack may
+// contain additional unknown items, i.e. Items is a lower bound.
+type StackDepth struct {
+	Items int  `json:"items"`
+	Open  bool `json:"open,omitempty"`
+}
+
+func depthOf(s *stack.Stack) StackDepth {
+	return StackDepth{Items: s.Len(), Open: s.HasWildcard()}
+}
+
+func (sd StackDepth) String() string {
+	if sd.Open {
+		return fmt.Sprintf("%d+", sd.Items)
+	}
+	return fmt.Sprint(sd.Items)
+}
+
+// CFGEdge is an edge of the control flow graph.
+type CFGEdge struct {
+	From int         `json:"from"`
+	To   int         `json:"to"` // -1 for dynamic jumps
+	Kind CFGEdgeKind `json:"kind"`
+}
+
+// CFGEdgeKind is the type of a control flow graph edge.
+type CFGEdgeKind string
+
+const (
+	FallThrough CFGEdgeKind = "fallthrough" // execution continues in the next block
+	StaticJump  CFGEdgeKind = "jump"        // jump to a location pushed just before
+	DynamicJump CFGEdgeKind = "dynamic"     // jump to a location computed at runtime
+)
+
+// ControlFlowGraph splits the bytecode into basic blocks and returns the graph of
+// jumps between them. Blocks start at every JUMPDEST and end after jumps, terminal
+// instructions and undefined opcodes. A jump is static when the location is pushed
+// by the instruction right before it. Stack depths are computed like the stack
+// comments, see SetStackComments.
+//
+// Data regions and symbols are handled just like in Disassemble.
+func (d *Disassembler) ControlFlowGraph(bytecode []byte) *CFG {
+	d.analyze(bytecode, true)
+
+	g := &CFG{Blocks: []*CFGBlock{}, Edges: []CFGEdge{}}
+	for i, blk := range d.stackComments.blocks {
+		first := blk.instrs[0].pc
+		b := &CFGBlock{ID: i, PC: first, StackIn: blk.entryDepth}
+		if d.jumpTargets[first] {
+			b.Label = d.labelName(first)
+		}
+		for _, inst := range blk.instrs {
+			b.Instructions = append(b.Instructions, CFGInstruction{
+				PC:    inst.pc,
+				Text:  d.instructionText(bytecode, inst),
+				Stack: d.stackComments.depths[inst.pc],
+			})
+		}
+		g.Blocks = append(g.Blocks, b)
+
+		if blk.fallsThru {
+			g.Edges = append(g.Edges, CFGEdge{From: i, To: i + 1, Kind: FallThrough})
+		}
+		if blk.jump >= 0 {
+			g.Edges = append(g.Edges, CFGEdge{From: i, To: blk.jump, Kind: StaticJump})
+		}
+		if blk.dynamicJump {
+			g.Edges = append(g.Edges, CFGEdge{From: i, To: -1, Kind: DynamicJump})
+		}
+	}
+	return g
+}
+
+// instructionText renders a single instruction.
+func (d *Disassembler) instructionText(bytecode []byte, inst instruction) string {
+	out := &docBuilder{doc: new(ast.Document)}
+	code := bytecode[:inst.end]
+	if name, ok := d.labelRefs[inst.pc]; ok {
+		d.addLabelPush(out, inst.op, code[inst.pc:], name)
+	} else if target, ok := pushValue(inst); ok && d.jumpTargets[target] {
+		out.add(d.opcode(inst.op, &ast.LabelRefExpr{Ident: d.labelName(target)}))
+	} else if inst.op == nil {
+		out.add(&ast.Bytes{Value: hexLiteral(code[inst.pc:])})
+	} else if inst.op.Push {
+		d.addPush(out, inst.op, code[inst.pc:])
+	} else if inst.op.HasImmediate {
+		d.addImmediate(out, inst.op, code[inst.pc:])
+	} else {
+		out.add(d.opcode(inst.op, nil))
+	}
+
+	var (
+		text strings.Builder
+		p    printer.Printer
+	)
+	p.SetIndent("")
+	p.Document(&text, out.doc)
+	return strings.TrimSpace(text.String())
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func (g *CFG) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph cfg {")
+	fmt.Fprintln(bw, `  node [shape=box fontname="monospace"];`)
+	dynamic := false
+	for _, b := range g.Blocks {
+		var label strings.Builder
+		if b.Label != "" {
+			label.WriteString(dotEscape(b.Label) + ":")
+		} else {
+			fmt.Fprintf(&label, "%04x:", b.PC)
+		}
+		fmt.Fprintf(&label, "  ; stack %v\\l", b.StackIn)
+		for _, inst := range b.Instructions {
+			fmt.Fprintf(&label, "%04x  %-24s ; %v\\l", inst.PC, dotEscape(inst.Text), inst.Stack)
+		}
+		fmt.Fprintf(bw, "  b%d [label=\"%s\"];\n", b.ID, label.String())
+	}
+	for _, e := range g.Edges {
+		switch e.Kind {
+		case FallThrough:
+			fmt.Fprintf(bw, "  b%d -> b%d [style=dashed];\n", e.From, e.To)
+		case StaticJump:
+			fmt.Fprintf(bw, "  b%d -> b%d;\n", e.From, e.To)
+		case DynamicJump:
+			fmt.Fprintf(bw, "  b%d -> dynamic [style=dotted];\n", e.From)
+			dynamic = true
+		}
+	}
+	if dynamic {
+		fmt.Fprintln(bw, `  dynamic [shape=diamond label="?"];`)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotEscape escapes text for use in a DOT string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fjl/geas/asm"
+)
+
+func TestControlFlowGraph(t *testing.T) {
+	bytecode, _ := hex.DecodeString("60013561000a57600250" + "5b5f3556" + "5b00")
+	g := New().ControlFlowGraph(bytecode)
+
+	wantEdges := []CFGEdge{
+		{From: 0, To: 1, Kind: FallThrough},
+		{From: 0, To: 2, Kind: StaticJump},
+		{From: 1, To: 2, Kind: FallThrough},
+		{From: 2, To: -1, Kind: DynamicJump},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Fatalf("wrong edges:\ngot:  %v\nwant: %v", g.Edges, wantEdges)
+	}
+
+	type blockInfo struct {
+		pc, n   int
+		label   string
+		stackIn StackDepth
+	}
+	wantBlocks := []blockInfo{
+		{pc: 0x00, n: 4, stackIn: StackDepth{}},
+		{pc: 0x07, n: 2, stackIn: StackDepth{}},
+		{pc: 0x0a, n: 4, label: "L_000a", stackIn: StackDepth{}},
+		{pc: 0x0e, n: 2, stackIn: StackDepth{Open: true}},
+	}
+	if len(g.Blocks) != len(wantBlocks) {
+		t.Fatalf("wrong number of blocks %d, want %d", len(g.Blocks), len(wantBlocks))
+	}
+	for i, want := range wantBlocks {
+		b := g.Blocks[i]
+		got := blockInfo{b.PC, len(b.Instructions), b.Label, b.StackIn}
+		if got != want {
+			t.Errorf("block %d: got %+v, want %+v", i, got, want)
+		}
+	}
+	if text := g.Blocks[0].Instructions[2].Text; text != "push2 @L_000a" {
+		t.Errorf("wrong jump push text %q", text)
+	}
+}
+
+func TestControlFlowGraphSymbols(t *testing.T) {
+	a := asm.New(nil)
+	bytecode := a.CompileString(`
+    push 1
+    jumpi @done
+    push 0
+    push 0
+    revert
+done:
+    stop
+    #bytes table: 0x0102
+`)
+	if a.Failed() {
+		t.Fatal(a.ErrorsAndWarnings())
+	}
+	d := New()
+	d.SetSymbols(a.Symbols())
+	g := d.ControlFlowGraph(bytecode)
+
+	if len(g.Blocks) != 3 {
+		t.Fatalf("wrong number of blocks %d, want 3", len(g.Blocks))
+	}
+	if g.Blocks[2].Label != "done" || len(g.Blocks[2].Instructions) != 2 {
+		t.Errorf("wrong last block %+v", g.Blocks[2])
+	}
+
+	var dot strings.Builder
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`b2 [label="done:  ; stack 0\l`,
+		`b0 -> b1 [style=dashed];`,
+		`b0 -> b2;`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT output does not contain %q:\n%s", want, dot.String())
+		}
+	}
+	if strings.Contains(dot.String(), "dynamic") {
+		t.Errorf("DOT output has dynamic jump node:\n%s", dot.String())
+	}
+}
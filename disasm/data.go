@@ -0,0 +1,230 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/fjl/geas/internal/ast"
+	"github.com/fjl/geas/internal/evm"
+)
+
+// dataRegion is a range of bytecode which is printed as #bytes.
+type dataRegion struct {
+	start, end int
+	comments   []string
+	name       string // set for named bytes
+	text       bool   // render printable text as strings
+}
+
+const (
+	dataLineBytes = 32 // bytes per line of hex data
+	minTextLength = 8  // minimum length of printable text to render as string
+)
+
+// dataOp stands in for data regions when computing block boundaries.
+var dataOp = &evm.Op{Name: "#bytes", Term: true, JumpDest: true}
+
+// findDataRegions detects the parts of bytecode which are not executable code.
+// These are:
+//
+//   - solc metadata at the end of the code
+//   - code regions that cannot be reached from the entry point or any JUMPDEST
+//   - regions accessed by CODECOPY with constant offset and length
+func (d *Disassembler) findDataRegions(bytecode []byte) []dataRegion {
+	const (
+		kindCode = iota
+		kindUnreachable
+		kindCopied
+		kindMetadata
+	)
+	var (
+		kind     = make([]byte, len(bytecode))
+		copiedBy = make(map[int]int) // region start -> pc of CODECOPY
+		codeEnd  = len(bytecode)
+		metadata []string
+	)
+	if start, comments, ok := decodeMetadata(bytecode); ok {
+		codeEnd = start
+		metadata = comments
+		for i := start; i < len(bytecode); i++ {
+			kind[i] = kindMetadata
+		}
+	}
+
+	// Walk the code, tracking whether the current instruction is reachable.
+	// Constant stack values are tracked within each block to find CODECOPY arguments.
+	var (
+		reachable = true
+		stack     []constValue
+	)
+	for pc := 0; pc < codeEnd; pc++ {
+		op := d.evm.OpByCode(bytecode[pc])
+		size := 0
+		if op != nil {
+			size = immediateSize(op, bytecode[pc:codeEnd])
+		}
+		if op != nil && op.JumpDest {
+			reachable = true
+			stack = stack[:0]
+		}
+		if !reachable {
+			for i := pc; i <= pc+size; i++ {
+				kind[i] = max(kind[i], kindUnreachable)
+			}
+		} else if op != nil {
+			var imm byte
+			if op.HasImmediate && size > 0 {
+				imm = bytecode[pc+1]
+			}
+			var in []constValue
+			stack, in = simulate(stack, op, imm, bytecode[pc+1:pc+1+size])
+			if op.Name == "CODECOPY" && in[1].known && in[2].known {
+				start, length := in[1].v, in[2].v
+				end := min(start+length, uint64(codeEnd))
+				// Copies of the code region containing the instruction itself are ignored.
+				if start < end && !(start <= uint64(pc) && uint64(pc) < end) {
+					for i := start; i < end; i++ {
+						kind[i] = max(kind[i], kindCopied)
+					}
+					if _, ok := copiedBy[int(start)]; !ok {
+						copiedBy[int(start)] = pc
+					}
+				}
+			}
+		}
+		if op == nil || op.Term || op.Unconditional {
+			reachable = false
+			stack = stack[:0]
+		}
+		pc += size
+	}
+
+	// Collect regions.
+	var regions []dataRegion
+	for pc := 0; pc < len(kind); {
+		k := kind[pc]
+		end := pc + 1
+		for end < len(kind) && kind[end] == k {
+			end++
+		}
+		r := dataRegion{start: pc, end: end, text: true}
+		switch k {
+		case kindUnreachable:
+			r.comments = []string{"unreachable code"}
+		case kindCopied:
+			if copier, ok := copiedBy[pc]; ok {
+				r.comments = []string{fmt.Sprintf("data copied by CODECOPY at 0x%0*x", len(d.pcHex), copier)}
+			} else {
+				r.comments = []string{"data copied by CODECOPY"}
+			}
+		case kindMetadata:
+			r.comments = append([]string{"compiler metadata"}, metadata...)
+			r.text = false
+		}
+		if k != kindCode {
+			regions = append(regions, r)
+		}
+		pc = end
+	}
+	return regions
+}
+
+// constValue is a stack item in the constant tracker.
+type constValue struct {
+	v     uint64
+	known bool
+}
+
+// simulate applies the stack effect of an instruction. It returns the new stack and the
+// input values of the instruction.
+func simulate(stack []constValue, op *evm.Op, imm byte, data []byte) ([]constValue, []constValue) {
+	if op.Push {
+		val := constValue{known: len(data) <= 8 && len(data) == op.PushSize()}
+		for _, b := range data {
+			val.v = val.v<<8 | uint64(b)
+		}
+		return append(stack, val), nil
+	}
+
+	// Pop inputs. Items below the start of the block are unknown.
+	inNames, outNames := op.StackIn(imm), op.StackOut(imm)
+	in := make([]constValue, len(inNames))
+	for i := range in {
+		if len(stack) > 0 {
+			in[i] = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+	}
+	// Outputs with the same name as an input carry over its value. This handles DUP,
+	// SWAP and similar.
+	for i := len(outNames) - 1; i >= 0; i-- {
+		var val constValue
+		if j := slices.Index(inNames, outNames[i]); j >= 0 {
+			val = in[j]
+		}
+		stack = append(stack, val)
+	}
+	return stack, in
+}
+
+// addData adds a data region as #bytes directives.
+func (d *Disassembler) addData(out *docBuilder, bytecode []byte, r dataRegion) {
+	for _, c := range r.comments {
+		out.add(&ast.Comment{Text: ";; " + c})
+	}
+	for pc := r.start; pc < r.end; {
+		d.addPrefix(out, pc, nil)
+		data := bytecode[pc:r.end]
+		if r.name != "" {
+			// Named bytes are printed as a single value, so the name refers to
+			// all of it.
+			st := &ast.Bytes{Label: &ast.LabelDef{Ident: r.name, Dotted: true}}
+			if r.text && textLength(data) == len(data) {
+				st.Value = ast.MakeStringLiteral(data)
+			} else {
+				st.Value = hexLiteral(data)
+			}
+			out.add(st)
+			break
+		}
+		if n := textLength(data); r.text && n >= minTextLength {
+			n = min(n, 2*dataLineBytes)
+			out.add(&ast.Bytes{Value: ast.MakeStringLiteral(data[:n])})
+			pc += n
+			continue
+		}
+		// Print hex up to the next text segment.
+		n := 0
+		for n < len(data) && n < dataLineBytes && !(r.text && textLength(data[n:]) >= minTextLength) {
+			n++
+		}
+		out.add(&ast.Bytes{Value: hexLiteral(data[:n])})
+		pc += n
+	}
+}
+
+// textLength returns the length of the printable ASCII prefix of data.
+func textLength(data []byte) int {
+	for i, b := range data {
+		if b < 0x20 || b > 0x7e {
+			return i
+		}
+	}
+	return len(data)
+}
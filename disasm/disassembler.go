@@ -18,14 +18,19 @@
 package disasm
 
 import (
-	"bufio"
+	"cmp"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/ast"
 	"github.com/fjl/geas/internal/evm"
+	"github.com/fjl/geas/internal/lzint"
+	"github.com/fjl/geas/internal/printer"
 )
 
 // Disassembler turns EVM bytecode into readable text instructions.
@@ -34,9 +39,25 @@ type Disassembler struct {
 	uppercase bool
 	showPC    bool
 	pcLabels  bool
+	symbolic  bool
+	data      bool
+	stack     bool
+	sigs      *Signatures
+	syms      *asm.Symbols
 	noBlanks  bool
 
+	commentCol    int
+	commentColSet bool
+
 	pcBuffer, pcHex []byte
+	jumpTargets     map[int]bool
+	regions         []dataRegion
+	stackComments   *stackAnnotator
+
+	// symbol table information, assigned by resolveSymbols
+	labelNames   map[int]string   // jump target pc -> label name
+	dottedLabels map[int][]string // pc -> dotted labels
+	labelRefs    map[int]string   // PUSH pc -> referenced label
 }
 
 func (d *Disassembler) setDefaults() {
@@ -76,6 +97,50 @@ func (d *Disassembler) SetPCLabels(on bool) {
 	d.pcLabels = on
 }
 
+// SetSymbolicLabels toggles control-flow recovery. In this mode, PUSH instructions
+// followed by JUMP or JUMPI are recognized, and the JUMPDEST they target receives a
+// named label. The jump is then printed as a jump to the label, e.g. 'jump @L_0012'.
+// JUMPDESTs which are not the target of any such jump are printed as plain 'jumpdest'.
+//
+// The output of this mode re-assembles to the original bytecode.
+func (d *Disassembler) SetSymbolicLabels(on bool) {
+	d.symbolic = on
+}
+
+// SetDetectData toggles detection of non-code regions. When enabled, compiler metadata
+// at the end of the code, unreachable code, and regions read by CODECOPY are printed
+// as #bytes.
+func (d *Disassembler) SetDetectData(on bool) {
+	d.data = on
+}
+
+// SetStackComments toggles annotation of instructions with stack comments. The comments
+// are computed by symbolic execution of the code.
+func (d *Disassembler) SetStackComments(on bool) {
+	d.stack = on
+}
+
+// SetSignatures sets the signature database. PUSH4 and PUSH32 instructions with a
+// value matching a signature are printed using the selector() and keccak256() builtins.
+func (d *Disassembler) SetSignatures(db *Signatures) {
+	d.sigs = db
+}
+
+// SetSymbols sets the symbol table of the program, as created by the assembler. Label
+// names, label references and #bytes regions are restored from the table, as far as
+// they match the bytecode. Jumps to labels which are not in the table are printed
+// like in symbolic label mode.
+func (d *Disassembler) SetSymbols(syms *asm.Symbols) {
+	d.syms = syms
+}
+
+// SetCommentColumn sets the column of line comments. By default, the column is
+// chosen automatically, like geas -f does it.
+func (d *Disassembler) SetCommentColumn(col int) {
+	d.commentCol = col
+	d.commentColSet = true
+}
+
 // SetShowBlocks toggles printing of blank lines at block boundaries.
 func (d *Disassembler) SetShowBlocks(on bool) {
 	d.noBlanks = !on
@@ -84,42 +149,136 @@ func (d *Disassembler) SetShowBlocks(on bool) {
 // Disassemble is the main entry point of the disassembler.
 // It runs through the bytecode and emits text to outW.
 func (d *Disassembler) Disassemble(bytecode []byte, outW io.Writer) error {
-	d.setDefaults()
-	d.pcBuffer = make([]byte, digitsOfPC(len(bytecode)))
-	d.pcHex = make([]byte, hex.EncodedLen(len(d.pcBuffer)))
-	out := bufio.NewWriter(outW)
+	doc := d.document(bytecode)
+	var p printer.Printer
+	if !d.indented() {
+		p.SetIndent("")
+	}
+	if d.commentColSet {
+		p.SetCommentColumn(d.commentCol)
+	}
+	return p.Document(outW, doc)
+}
+
+// document disassembles the bytecode into a syntax tree.
+func (d *Disassembler) document(bytecode []byte) *ast.Document {
+	d.analyze(bytecode, false)
 
+	out := &docBuilder{doc: new(ast.Document)}
 	var prevOp *evm.Op
 	for pc := 0; pc < len(bytecode); pc++ {
-		op := d.evm.OpByCode(bytecode[pc])
-		d.newline(out, prevOp, op)
-		d.printPrefix(out, pc, op)
+		if r := d.regionAt(pc); r != nil {
+			d.blockBreak(out, prevOp, dataOp)
+			d.addDottedLabels(out, pc)
+			d.addData(out, bytecode, *r)
+			prevOp = dataOp
+			pc = r.end - 1
+			continue
+		}
+		code := bytecode[:d.codeEnd(pc, len(bytecode))]
+		op := d.evm.OpByCode(code[pc])
+		d.blockBreak(out, prevOp, op)
+		d.addDottedLabels(out, pc)
+		if d.jumpTargets[pc] {
+			// The label emits the JUMPDEST.
+			st := d.addLabel(out, pc)
+			if d.stackComments != nil {
+				setStackComment(st, d.stackComments.labelComments[pc])
+			}
+			prevOp = op
+			continue
+		}
+		d.addPrefix(out, pc, op)
 		if op == nil {
-			d.printInvalid(out, bytecode[pc])
+			d.addInvalid(out, bytecode[pc])
 		} else {
-			if op.Push {
-				size := d.printPush(out, op, bytecode[pc:])
-				pc += size
+			var (
+				st        ast.Statement
+				size      int
+				commentPC = pc
+			)
+			if target, jump, ok := d.staticJump(code, pc); ok && d.jumpTargets[target] {
+				st, size = d.addJump(out, pc, op, jump, target)
+				commentPC = pc + size
+				op = jump
+			} else if name, ok := d.labelRefs[pc]; ok {
+				st, size = d.addLabelPush(out, op, code[pc:], name)
+			} else if op.Push {
+				st, size = d.addPush(out, op, code[pc:])
 			} else if op.HasImmediate {
-				size := d.printImmediate(out, op, bytecode[pc:])
-				pc += size
+				st, size = d.addImmediate(out, op, code[pc:])
 			} else {
-				d.printOp(out, op)
+				st = out.add(d.opcode(op, nil))
+			}
+			pc += size
+			if d.stackComments != nil {
+				setStackComment(st, d.stackComments.comments[commentPC])
 			}
 		}
 
 		prevOp = op
 	}
-	d.newline(out, prevOp, nil)
-	return out.Flush()
+	d.addDottedLabels(out, len(bytecode))
+	return out.doc
 }
 
-func (d *Disassembler) printPrefix(out io.Writer, pc int, op *evm.Op) {
+// analyze decodes the bytecode and runs the enabled analysis passes. Jump targets and
+// stack depths are always computed in control flow graph mode.
+func (d *Disassembler) analyze(bytecode []byte, cfg bool) []instruction {
+	d.setDefaults()
+	d.pcBuffer = make([]byte, digitsOfPC(len(bytecode)))
+	d.pcHex = make([]byte, hex.EncodedLen(len(d.pcBuffer)))
+	d.jumpTargets, d.regions, d.stackComments = nil, nil, nil
+	d.labelNames, d.dottedLabels, d.labelRefs = nil, nil, nil
+	if d.data {
+		d.regions = d.findDataRegions(bytecode)
+	}
+	if d.syms != nil {
+		d.regions = mergeRegions(d.regions, d.symbolRegions(len(bytecode)))
+	}
+	instrs := d.instructions(bytecode)
+	if d.symbolic || cfg {
+		d.jumpTargets = d.findJumpTargets(instrs)
+	}
+	if d.syms != nil {
+		d.resolveSymbols(instrs, len(bytecode))
+	}
+	if d.stack || cfg {
+		d.stackComments = d.computeStackComments(instrs, cfg)
+	}
+	return instrs
+}
+
+// indented reports whether instructions are indented in the output. This is the case
+// when labels are printed on their own line.
+func (d *Disassembler) indented() bool {
+	return !d.showPC && (d.symbolic || d.syms != nil || d.pcLabels)
+}
+
+// docBuilder collects the statements of the disassembly.
+type docBuilder struct {
+	doc        *ast.Document
+	startBlock bool // whether the next statement starts a block
+}
+
+// add appends a statement to the document.
+func (b *docBuilder) add(st ast.Statement) ast.Statement {
+	if b.startBlock && len(b.doc.Statements) > 0 {
+		st.SetStartsBlock(true)
+	}
+	b.startBlock = false
+	b.doc.Statements = append(b.doc.Statements, st)
+	return st
+}
+
+// addPrefix adds the PC label of an instruction, if enabled.
+func (d *Disassembler) addPrefix(out *docBuilder, pc int, op *evm.Op) {
 	switch {
 	case d.showPC:
-		io.WriteString(out, "0x")
-		d.printPC(out, pc)
-		io.WriteString(out, ": ")
+		text := "0x" + d.pcString(pc)
+		out.add(&ast.PCLabel{PC: uint64(pc), Text: text, Inline: true})
+	case d.symbolic, d.syms != nil:
+		// Symbolic labels take precedence over PC labels.
 	case d.pcLabels:
 		// In this mode, output is formatted like geas -f would: labels are on
 		// their own line, and instructions are indented.
@@ -130,83 +289,229 @@ func (d *Disassembler) printPrefix(out io.Writer, pc int, op *evm.Op) {
 			if len(s)%2 == 1 {
 				s = "0" + s
 			}
-			fmt.Fprintf(out, "0x%s:\n", s)
+			out.add(&ast.PCLabel{PC: uint64(pc), Text: "0x" + s})
 		}
-		io.WriteString(out, "    ")
 	}
 }
 
-func (d *Disassembler) printPC(out io.Writer, pc int) {
+// findJumpTargets returns the JUMPDESTs targeted by static jumps in the bytecode.
+func (d *Disassembler) findJumpTargets(instrs []instruction) map[int]bool {
+	var (
+		jumpdests = make(map[int]bool)
+		jumps     []int
+	)
+	for i, inst := range instrs {
+		if inst.op == nil {
+			continue
+		}
+		if inst.op.JumpDest {
+			jumpdests[inst.pc] = true
+		}
+		if inst.op.Jump && i > 0 && instrs[i-1].pc == inst.pc-len(instrs[i-1].data)-1 {
+			if target, ok := pushValue(instrs[i-1]); ok {
+				jumps = append(jumps, target)
+			}
+		}
+	}
+
+	targets := make(map[int]bool)
+	for _, target := range jumps {
+		if jumpdests[target] {
+			targets[target] = true
+		}
+	}
+	return targets
+}
+
+// regionAt returns the data region starting at pc.
+func (d *Disassembler) regionAt(pc int) *dataRegion {
+	i, found := d.findRegion(pc)
+	if !found {
+		return nil
+	}
+	return &d.regions[i]
+}
+
+// codeEnd returns the end of the code segment containing pc.
+func (d *Disassembler) codeEnd(pc int, codesize int) int {
+	if i, _ := d.findRegion(pc); i < len(d.regions) {
+		return d.regions[i].start
+	}
+	return codesize
+}
+
+func (d *Disassembler) findRegion(pc int) (int, bool) {
+	return slices.BinarySearchFunc(d.regions, pc, func(r dataRegion, pc int) int {
+		return cmp.Compare(r.start, pc)
+	})
+}
+
+// staticJump checks whether the instruction at pc is a PUSH followed by JUMP or JUMPI.
+// It returns the pushed jump target and the jump instruction.
+func (d *Disassembler) staticJump(bytecode []byte, pc int) (target int, jump *evm.Op, ok bool) {
+	op := d.evm.OpByCode(bytecode[pc])
+	if op == nil || !op.Push {
+		return 0, nil, false
+	}
+	size := op.PushSize()
+	if size == 0 || size > 4 || pc+size+1 >= len(bytecode) {
+		return 0, nil, false
+	}
+	jump = d.evm.OpByCode(bytecode[pc+size+1])
+	if jump == nil || !jump.Jump {
+		return 0, nil, false
+	}
+	for _, b := range bytecode[pc+1 : pc+size+1] {
+		target = target<<8 | int(b)
+	}
+	return target, jump, true
+}
+
+// pushValue returns the value of a PUSH instruction which can be a jump target.
+func pushValue(push instruction) (int, bool) {
+	if push.op == nil || !push.op.Push || len(push.data) == 0 || len(push.data) > 4 {
+		return 0, false
+	}
+	var v int
+	for _, b := range push.data {
+		v = v<<8 | int(b)
+	}
+	return v, true
+}
+
+// immediateSize returns the number of bytes following the instruction at code[0].
+// This must match the way Disassemble advances through the code.
+func immediateSize(op *evm.Op, code []byte) int {
+	switch {
+	case op.Push:
+		return min(op.PushSize(), len(code)-1)
+	case op.HasImmediate:
+		if len(code) < 2 {
+			return 0
+		}
+		if !op.ValidateImmediate(code[1]) {
+			return 0
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+// addLabel adds the symbolic label of a jump target.
+func (d *Disassembler) addLabel(out *docBuilder, pc int) ast.Statement {
+	return out.add(&ast.LabelDef{Ident: d.labelName(pc)})
+}
+
+// addJump adds a static jump to a labeled target. It returns the statement of the
+// jump and the number of bytes consumed after the PUSH opcode.
+func (d *Disassembler) addJump(out *docBuilder, pc int, push *evm.Op, jump *evm.Op, target int) (ast.Statement, int) {
+	size := push.PushSize()
+	ref := &ast.LabelRefExpr{Ident: d.labelName(target)}
+	if size == minPushSize(target) {
+		// The assembler creates the same PUSH for 'jump @label'.
+		return out.add(d.opcode(jump, ref)), size + 1
+	}
+	st := out.add(d.opcode(push, ref))
+	if d.stackComments != nil {
+		setStackComment(st, d.stackComments.comments[pc])
+	}
+	d.addPrefix(out, pc+size+1, jump)
+	return out.add(d.opcode(jump, nil)), size + 1
+}
+
+// minPushSize returns the size of the PUSH created by the assembler for a label value.
+func minPushSize(v int) int {
+	size := 1
+	for v > 0xff {
+		v >>= 8
+		size++
+	}
+	return size
+}
+
+// pcString returns the program counter as padded hex.
+func (d *Disassembler) pcString(pc int) string {
 	for i := range d.pcBuffer {
 		d.pcBuffer[len(d.pcBuffer)-1-i] = byte(pc >> (8 * i))
 	}
 	hex.Encode(d.pcHex, d.pcBuffer)
-	out.Write(d.pcHex)
+	return string(d.pcHex)
 }
 
-func (d *Disassembler) printInvalid(out io.Writer, b byte) {
-	fmt.Fprintf(out, "#bytes %#x\n", b)
+func (d *Disassembler) addInvalid(out *docBuilder, b byte) {
+	out.add(&ast.Bytes{Value: hexLiteral([]byte{b})})
 }
 
-func (d *Disassembler) printOp(out io.Writer, op *evm.Op) {
-	name := op.Name
-	if !d.uppercase {
-		name = strings.ToLower(op.Name)
+// opcode creates an instruction statement.
+func (d *Disassembler) opcode(op *evm.Op, arg ast.Expr) *ast.Opcode {
+	return &ast.Opcode{Op: d.opName(op.Name), Arg: arg}
+}
+
+func (d *Disassembler) opName(name string) string {
+	if d.uppercase {
+		return strings.ToUpper(name)
 	}
-	fmt.Fprint(out, name)
+	return strings.ToLower(name)
 }
 
-func (d *Disassembler) printPush(out io.Writer, op *evm.Op, code []byte) (dataSize int) {
+func (d *Disassembler) addPush(out *docBuilder, op *evm.Op, code []byte) (st ast.Statement, dataSize int) {
 	size := op.PushSize()
 	if size == 0 {
-		d.printOp(out, op)
-		return 0
+		return out.add(d.opcode(op, nil)), 0
 	}
 	if size > len(code)-1 {
 		// Handle truncated PUSH at end of code.
-		fmt.Fprintf(out, "#bytes %#x", code)
-		return len(code) - 1
+		return out.add(&ast.Bytes{Value: hexLiteral(code)}), len(code) - 1
 	}
-	d.printOp(out, op)
 	data := code[1 : size+1]
-	fmt.Fprintf(out, " %#x", data)
-	return len(data)
+	var arg ast.Expr
+	if sig, ok := d.sigs.Selector(data); ok {
+		arg = builtinCall("selector", sig)
+	} else if sig, ok := d.sigs.Hash(data); ok {
+		arg = builtinCall("keccak256", sig)
+	} else {
+		arg = hexLiteral(data)
+	}
+	return out.add(d.opcode(op, arg)), len(data)
 }
 
-func (d *Disassembler) printImmediate(out io.Writer, op *evm.Op, code []byte) (dataSize int) {
+func (d *Disassembler) addImmediate(out *docBuilder, op *evm.Op, code []byte) (st ast.Statement, dataSize int) {
 	if len(code) < 2 {
 		// Truncated instruction
-		fmt.Fprintf(out, "#bytes %#x", code)
-		return len(code) - 1
+		return out.add(&ast.Bytes{Value: hexLiteral(code)}), len(code) - 1
 	}
 	imm := code[1]
 	if !op.ValidateImmediate(imm) {
-		fmt.Fprintf(out, "#bytes %#x   ; invalid %s", code[0], op.Name)
-		return 0
-	}
-	d.printOp(out, op)
-	args := op.DecodeImmediate(imm)
-	fmt.Fprint(out, "[")
-	for i, arg := range args {
-		if i > 0 {
-			fmt.Fprint(out, ", ")
-		}
-		fmt.Fprintf(out, "%d", arg)
+		st := &ast.Bytes{Value: hexLiteral(code[:1])}
+		st.SetComment(&ast.Comment{Text: "; invalid " + op.Name})
+		return out.add(st), 0
 	}
-	fmt.Fprint(out, "]")
-	return 1
+	inst := d.opcode(op, nil)
+	inst.Immediates = op.DecodeImmediate(imm)
+	return out.add(inst), 1
 }
 
-func (d *Disassembler) newline(out io.Writer, prevOp *evm.Op, nextOp *evm.Op) {
-	if prevOp == nil {
-		return
+// hexLiteral creates a hex number literal. Leading zero bytes are kept.
+func hexLiteral(data []byte) *ast.LiteralExpr {
+	return ast.MakeNumber(lzint.FromBytes(data))
+}
+
+// builtinCall creates a call of a builtin macro with a string argument.
+func builtinCall(name, arg string) *ast.MacroCallExpr {
+	return &ast.MacroCallExpr{
+		Ident: name,
+		Args:  []ast.Expr{ast.MakeStringLiteral([]byte(arg))},
 	}
-	out.Write([]byte{'\n'})
-	if d.noBlanks || nextOp == nil {
+}
+
+// blockBreak starts a new block of statements at the boundaries of basic blocks.
+func (d *Disassembler) blockBreak(out *docBuilder, prevOp *evm.Op, nextOp *evm.Op) {
+	if prevOp == nil || d.noBlanks || nextOp == nil {
 		return
 	}
 	if prevOp.Jump || nextOp.JumpDest || prevOp.Term {
-		out.Write([]byte{'\n'})
+		out.startBlock = true
 	}
 }
 
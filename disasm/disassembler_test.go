@@ -19,6 +19,7 @@ package disasm
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -85,6 +86,222 @@ func TestPCLabels(t *testing.T) {
 	}
 }
 
+func TestSymbolicLabels(t *testing.T) {
+	// This has a jump to a JUMPDEST, a jump with oversized push, a push/jump
+	// to a non-JUMPDEST, and an unreferenced JUMPDEST.
+	bytecode, _ := hex.DecodeString("6001600a5761000a565b5b00600c565b")
+	expectedOutput := `    push1 0x01
+    jumpi @L_000a
+
+    push2 @L_000a
+    jump
+
+    jumpdest
+
+L_000a:
+    stop
+
+    push1 0x0c
+    jump
+
+    jumpdest`
+
+	var buf strings.Builder
+	d := New()
+	d.SetSymbolicLabels(true)
+	d.Disassemble(bytecode, &buf)
+	output := strings.TrimRight(buf.String(), "\n")
+	if output != expectedOutput {
+		t.Fatalf("wrong output:\ngot:\n%s\n\nwant:\n%s", output, expectedOutput)
+	}
+
+	// try round trip
+	a := asm.New(nil)
+	rtcode := a.CompileString(output)
+	if !bytes.Equal(rtcode, bytecode) {
+		t.Errorf("disassembly did not round-trip: %x %v", rtcode, a.Errors())
+	}
+}
+
+func TestDataRegions(t *testing.T) {
+	bytecode, _ := hex.DecodeString("600c60085f3900fe" + hex.EncodeToString([]byte(`say "hi" \o/`)))
+	expectedOutput := `push1 0x0c
+push1 0x08
+push0
+codecopy
+stop
+
+;; unreachable code
+#bytes 0xfe
+
+;; data copied by CODECOPY at 0x0005
+#bytes "say \"hi\" \\o/"`
+
+	var buf strings.Builder
+	d := New()
+	d.SetDetectData(true)
+	d.Disassemble(bytecode, &buf)
+	output := strings.TrimRight(buf.String(), "\n")
+	if output != expectedOutput {
+		t.Fatalf("wrong output:\ngot:\n%s\n\nwant:\n%s", output, expectedOutput)
+	}
+
+	// try round trip
+	a := asm.New(nil)
+	rtcode := a.CompileString(output)
+	if !bytes.Equal(rtcode, bytecode) {
+		t.Errorf("disassembly did not round-trip: %x %v", rtcode, a.Errors())
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	bytecode, _ := hex.DecodeString("6080604052348015600e575f80fd5b50603e80601a5f395ff3fe60806040525f80fdfea2646970667358221220ba4339602dd535d09d71fae3164f7aa7f6e098ec879fc9e8f36bd912d4877c5264736f6c63430008190033")
+	expectedOutput := `    push1 0x80
+    push1 0x40
+    mstore
+    callvalue
+    dup1
+    iszero
+    jumpi @L_000e
+
+    push0
+    dup1
+    revert
+
+L_000e:
+    pop
+    push1 0x3e
+    dup1
+    push1 0x1a
+    push0
+    codecopy
+    push0
+    return
+
+    ;; unreachable code
+#bytes 0xfe
+
+    ;; data copied by CODECOPY at 0x0016
+#bytes 0x60806040525f80fdfe
+
+    ;; compiler metadata
+    ;; ipfs: 0x1220ba4339602dd535d09d71fae3164f7aa7f6e098ec879fc9e8f36bd912d4877c52
+    ;; solc: 0.8.25
+#bytes 0xa2646970667358221220ba4339602dd535d09d71fae3164f7aa7f6e098ec879f
+#bytes 0xc9e8f36bd912d4877c5264736f6c63430008190033`
+
+	var buf strings.Builder
+	d := New()
+	d.SetTarget("cancun")
+	d.SetDetectData(true)
+	d.SetSymbolicLabels(true)
+	d.Disassemble(bytecode, &buf)
+	output := strings.TrimRight(buf.String(), "\n")
+	if output != expectedOutput {
+		t.Fatalf("wrong output:\ngot:\n%s\n\nwant:\n%s", output, expectedOutput)
+	}
+
+	// try round trip
+	a := asm.New(nil)
+	rtcode := a.CompileString(output)
+	if !bytes.Equal(rtcode, bytecode) {
+		t.Errorf("disassembly did not round-trip: %v", a.Errors())
+	}
+}
+
+func TestStackComments(t *testing.T) {
+	a := asm.New(nil)
+	bytecode := a.CompileString(`
+    push 0
+    push 10
+loop:
+    swap1
+    push 1
+    add
+    swap1
+    push 1
+    swap1
+    sub
+    dup1
+    jumpi @loop
+    jump
+    jumpdest
+    add
+    dup2
+    jump
+`)
+	expectedOutput := `    push0              ; [0]
+    push1 0x0a         ; [0x0a, 0]
+
+L_0003:                ; [v, v2]
+    swap1              ; [v2, v]
+    push1 0x01         ; [0x01, v2, v]
+    add                ; [0x01+v2, v]
+    swap1              ; [v, 0x01+v2]
+    push1 0x01         ; [0x01, v, 0x01+v2]
+    swap1              ; [v, 0x01, 0x01+v2]
+    sub                ; [v-0x01, 0x01+v2]
+    dup1               ; [v-0x01, v-0x01, 0x01+v2]
+    jumpi @L_0003      ; [v-0x01, 0x01+v2]
+
+    jump               ; [0x01+v2]
+
+    jumpdest           ; [..]
+    add                ; [x+y, ..]
+    dup2               ; [x2, x+y, x2, ..]
+    jump               ; [x+y, x2, ..]`
+
+	var buf strings.Builder
+	d := New()
+	d.SetSymbolicLabels(true)
+	d.SetStackComments(true)
+	d.Disassemble(bytecode, &buf)
+	output := strings.TrimRight(buf.String(), "\n")
+	if output != expectedOutput {
+		t.Fatalf("wrong output:\ngot:\n%s\n\nwant:\n%s", output, expectedOutput)
+	}
+
+	// The comments must pass the stack checker.
+	a = asm.New(nil)
+	a.SetStackCheck(true)
+	rtcode := a.CompileString(output)
+	if !bytes.Equal(rtcode, bytecode) {
+		t.Errorf("disassembly did not round-trip: %v", a.Errors())
+	}
+	for _, w := range a.Warnings() {
+		t.Error("warning:", w)
+	}
+}
+
+func TestSignatureAnnotation(t *testing.T) {
+	bytecode, _ := hex.DecodeString("63a9059cbb7fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef6370a08231")
+	expectedOutput := strings.TrimSpace(`
+push4 selector("transfer(address,uint256)")
+push32 keccak256("Transfer(address,address,uint256)")
+push4 0x70a08231
+`)
+	sigs := NewSignatures()
+	sigs.Add("transfer(address,uint256)")
+	sigs.Add("Transfer(address,address,uint256)")
+
+	var buf strings.Builder
+	d := New()
+	d.SetShowBlocks(false)
+	d.SetSignatures(sigs)
+	d.Disassemble(bytecode, &buf)
+	output := strings.TrimSpace(buf.String())
+	if output != expectedOutput {
+		t.Fatalf("wrong output:\ngot:\n%s\n\nwant:\n%s", output, expectedOutput)
+	}
+
+	// try round trip
+	a := asm.New(nil)
+	rtcode := a.CompileString(output)
+	if !bytes.Equal(rtcode, bytecode) {
+		t.Errorf("disassembly did not round-trip: %v", a.Errors())
+	}
+}
+
 func TestImmediateOpcodeTruncated(t *testing.T) {
 	bytecode, _ := hex.DecodeString("e6")
 	expectedOutput := "#bytes 0xe6"
@@ -104,15 +321,15 @@ func TestImmediateOpcodeTruncated(t *testing.T) {
 func TestImmediateOpcodeInvalid(t *testing.T) {
 	bytecode, _ := hex.DecodeString("e75be6605be7610000e65fe850")
 	expectedOutput := strings.TrimSpace(`
-#bytes 0xe7   ; invalid SWAPN
+#bytes 0xe7            ; invalid SWAPN
 jumpdest
-#bytes 0xe6   ; invalid DUPN
+#bytes 0xe6            ; invalid DUPN
 push1 0x5b
-#bytes 0xe7   ; invalid SWAPN
+#bytes 0xe7            ; invalid SWAPN
 push2 0x0000
-#bytes 0xe6   ; invalid DUPN
+#bytes 0xe6            ; invalid DUPN
 push0
-#bytes 0xe8   ; invalid EXCHANGE
+#bytes 0xe8            ; invalid EXCHANGE
 pop
 `)
 
@@ -194,3 +411,173 @@ dup8
 		t.Error("disassembly did not round-trip")
 	}
 }
+
+func TestSymbols(t *testing.T) {
+	src := `
+    push len(message)
+    push @message
+    push 0
+    codecopy
+    jump @main
+main:
+    push 1
+    push @.done
+    jumpi
+    jump @main
+.done:
+    stop
+#bytes message: "hello, symbols"
+`
+	a := asm.New(nil)
+	bytecode := a.CompileString(src)
+	if len(a.Errors()) > 0 {
+		t.Fatal(a.Errors())
+	}
+	expectedOutput := strings.TrimSpace(`
+    push1 0x0e
+    push @message
+    push0
+    codecopy
+    jump @main
+
+main:
+    push1 0x01
+    push @done
+    jumpi
+
+    jump @main
+
+.done:
+    stop
+
+#bytes message: "hello, symbols"
+`)
+
+	var buf strings.Builder
+	d := New()
+	d.SetSymbols(a.Symbols())
+	d.Disassemble(bytecode, &buf)
+	output := strings.TrimSpace(buf.String())
+	if output != expectedOutput {
+		t.Fatalf("wrong output:\ngot:\n%s\n\nwant:\n%s", output, expectedOutput)
+	}
+
+	// try round trip
+	rtcode := a.CompileString(output)
+	if !bytes.Equal(rtcode, bytecode) {
+		t.Errorf("disassembly did not round-trip: %v", a.Errors())
+	}
+}
+
+// This checks that symbols which do not match the code are ignored.
+func TestSymbolsMismatch(t *testing.T) {
+	bytecode, _ := hex.DecodeString("6005565b005b00")
+	syms := &asm.Symbols{
+		Labels: []asm.SymbolLabel{
+			{Name: "wrong", PC: 4},    // not a JUMPDEST
+			{Name: "inside", PC: 1},   // not an instruction boundary
+			{Name: "target", PC: 5},   // ok
+			{Name: "beyond", PC: 100}, // out of range
+		},
+		Data: []asm.SymbolData{{PC: 6, Size: 5}},
+		Refs: []asm.SymbolRef{{PC: 0, Label: "target"}},
+	}
+	expectedOutput := strings.TrimSpace(`
+jump @target
+
+    jumpdest
+.wrong:
+    stop
+
+target:
+    stop
+`)
+	var buf strings.Builder
+	d := New()
+	d.SetSymbols(syms)
+	d.Disassemble(bytecode, &buf)
+	output := strings.TrimSpace(buf.String())
+	if output != expectedOutput {
+		t.Fatalf("wrong output:\ngot:\n%s\n\nwant:\n%s", output, expectedOutput)
+	}
+}
+
+// FuzzRoundTrip checks that the disassembly of arbitrary bytecode assembles back to
+// the same bytecode, in all output modes.
+func FuzzRoundTrip(f *testing.F) {
+	sigs := NewSignatures()
+	sigs.Add("transfer(address,uint256)")
+	sigs.Add("Transfer(address,address,uint256)")
+
+	for _, code := range []string{
+		"",
+		"61",                         // truncated PUSH
+		"e6",                         // truncated DUPN
+		"e75be6605be7610000e65fe850", // invalid immediates
+		"6001600a5761000a565b5b00600c565b",
+		"600c60085f3900fe7361792022686922205c6f2f",
+		"6080604052348015600e575f80fd5b50603e80601a5f395ff3fe60806040525f80fdfea2646970667358221220ba4339602dd535d09d71fae3164f7aa7f6e098ec879fc9e8f36bd912d4877c5264736f6c63430008190033",
+		"63a9059cbb7fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", // signatures
+		"00a1650a73746f70010008", // metadata key with newline
+	} {
+		bytecode, _ := hex.DecodeString(code)
+		for mode := range 128 {
+			f.Add(bytecode, uint8(mode))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, bytecode []byte, mode uint8) {
+		d := New()
+		d.SetTarget("amsterdam")
+		d.SetShowPC(mode&1 != 0)
+		d.SetPCLabels(mode&2 != 0)
+		d.SetSymbolicLabels(mode&4 != 0)
+		d.SetDetectData(mode&8 != 0)
+		d.SetStackComments(mode&16 != 0)
+		if mode&32 != 0 {
+			d.SetSignatures(sigs)
+		}
+		if mode&64 != 0 {
+			d.SetSymbols(fuzzSymbols(bytecode))
+		}
+		var buf strings.Builder
+		if err := d.Disassemble(bytecode, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		a := asm.New(nil)
+		a.SetDefaultFork("amsterdam")
+		rtcode := a.CompileString(buf.String())
+		if len(a.Errors()) > 0 {
+			t.Fatalf("disassembly does not assemble: %v\n%s", a.Errors(), buf.String())
+		}
+		if !bytes.Equal(rtcode, bytecode) {
+			t.Fatalf("disassembly did not round-trip\ngot:  %x\nwant: %x\n%s", rtcode, bytecode, buf.String())
+		}
+	})
+}
+
+// fuzzSymbols creates a symbol table for arbitrary bytecode. The entries are derived
+// from the code bytes, so some of them match the instructions and others don't.
+func fuzzSymbols(bytecode []byte) *asm.Symbols {
+	syms := new(asm.Symbols)
+	for i, b := range bytecode {
+		pc := int(b) % (len(bytecode) + 1)
+		name := fmt.Sprintf("s%d", i/4)
+		switch i % 4 {
+		case 0:
+			syms.Labels = append(syms.Labels, asm.SymbolLabel{Name: name, PC: pc})
+		case 1:
+			syms.Labels = append(syms.Labels, asm.SymbolLabel{Name: name, PC: pc, Dotted: true})
+		case 2:
+			sd := asm.SymbolData{PC: pc, Size: int(b>>4) + 1}
+			if b&1 != 0 {
+				sd.Name = name
+			}
+			syms.Data = append(syms.Data, sd)
+		case 3:
+			syms.Refs = append(syms.Refs, asm.SymbolRef{PC: pc, Label: fmt.Sprintf("s%d", int(b)%(i/4+1))})
+		}
+	}
+	return syms
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// decodeMetadata detects compiler metadata at the end of bytecode. Solidity and Vyper
+// append a CBOR-encoded map followed by its length as a two-byte big-endian integer.
+// The function returns the start offset of the metadata and a description of its
+// content.
+func decodeMetadata(bytecode []byte) (start int, comments []string, ok bool) {
+	if len(bytecode) < 3 {
+		return 0, nil, false
+	}
+	length := int(binary.BigEndian.Uint16(bytecode[len(bytecode)-2:]))
+	start = len(bytecode) - 2 - length
+	if length == 0 || start < 0 {
+		return 0, nil, false
+	}
+	d := cborDecoder{data: bytecode[start : len(bytecode)-2]}
+	comments, err := d.metadataMap()
+	if err != nil || len(d.data) > 0 {
+		return 0, nil, false
+	}
+	return start, comments, true
+}
+
+var errInvalidCBOR = errors.New("invalid CBOR")
+
+// cborDecoder is a minimal CBOR decoder for the types used in compiler metadata.
+type cborDecoder struct {
+	data []byte
+}
+
+const (
+	cborUint   = 0
+	cborBytes  = 2
+	cborText   = 3
+	cborMap    = 5
+	cborSimple = 7
+)
+
+// header decodes the type and argument of a CBOR item.
+func (d *cborDecoder) header() (major byte, arg uint64, err error) {
+	if len(d.data) == 0 {
+		return 0, 0, errInvalidCBOR
+	}
+	major, info := d.data[0]>>5, d.data[0]&0x1f
+	d.data = d.data[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(d.data) < size {
+			return 0, 0, errInvalidCBOR
+		}
+		for _, b := range d.data[:size] {
+			arg = arg<<8 | uint64(b)
+		}
+		d.data = d.data[size:]
+		return major, arg, nil
+	default:
+		return 0, 0, errInvalidCBOR
+	}
+}
+
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if uint64(len(d.data)) < n {
+		return nil, errInvalidCBOR
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+// metadataMap decodes the metadata map. Values are rendered as 'key: value'.
+func (d *cborDecoder) metadataMap() ([]string, error) {
+	major, n, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	if major != cborMap || n == 0 {
+		return nil, errInvalidCBOR
+	}
+	var entries []string
+	for range n {
+		major, size, err := d.header()
+		if err != nil {
+			return nil, err
+		}
+		if major != cborText {
+			return nil, errInvalidCBOR
+		}
+		key, err := d.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		// Keys are printed unquoted in comments, so they must be printable text.
+		if !utf8.Valid(key) || bytes.ContainsFunc(key, func(r rune) bool { return !unicode.IsPrint(r) }) {
+			return nil, errInvalidCBOR
+		}
+		value, err := d.value(string(key))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fmt.Sprintf("%s: %s", key, value))
+	}
+	return entries, nil
+}
+
+func (d *cborDecoder) value(key string) (string, error) {
+	major, arg, err := d.header()
+	if err != nil {
+		return "", err
+	}
+	switch major {
+	case cborUint:
+		return fmt.Sprint(arg), nil
+	case cborBytes:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return "", err
+		}
+		if key == "solc" && len(b) == 3 {
+			return fmt.Sprintf("%d.%d.%d", b[0], b[1], b[2]), nil
+		}
+		return fmt.Sprintf("%#x", b), nil
+	case cborText:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%q", b), nil
+	case cborSimple:
+		switch arg {
+		case 20:
+			return "false", nil
+		case 21:
+			return "true", nil
+		}
+	}
+	return "", errInvalidCBOR
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signatures is a database of function, event and error signatures. It is used by the
+// disassembler to show PUSH4 selectors and PUSH32 hashes as the signatures they were
+// computed from.
+type Signatures struct {
+	selectors map[[4]byte]string
+	hashes    map[[32]byte]string
+}
+
+// NewSignatures creates an empty signature database.
+func NewSignatures() *Signatures {
+	return &Signatures{
+		selectors: make(map[[4]byte]string),
+		hashes:    make(map[[32]byte]string),
+	}
+}
+
+// Add adds a signature like "transfer(address,uint256)" to the database.
+// The 'function', 'event' and 'error' keywords are accepted in front of it.
+func (db *Signatures) Add(sig string) error {
+	sig = strings.TrimSpace(sig)
+	for _, kw := range []string{"function ", "event ", "error "} {
+		sig = strings.TrimSpace(strings.TrimPrefix(sig, kw))
+	}
+	if !strings.Contains(sig, "(") || !strings.HasSuffix(sig, ")") {
+		return fmt.Errorf("invalid signature %q", sig)
+	}
+	if _, err := abi.ParseSelector(sig); err != nil {
+		return fmt.Errorf("invalid signature %q", sig)
+	}
+	hash := crypto.Keccak256Hash([]byte(sig))
+	if _, ok := db.hashes[hash]; !ok {
+		db.hashes[hash] = sig
+	}
+	if sel := [4]byte(hash[:4]); db.selectors[sel] == "" {
+		db.selectors[sel] = sig
+	}
+	return nil
+}
+
+// Len returns the number of signatures in the database.
+func (db *Signatures) Len() int {
+	return len(db.hashes)
+}
+
+// Selector returns the signature matching a 4-byte selector.
+func (db *Signatures) Selector(sel []byte) (string, bool) {
+	if db == nil || len(sel) != 4 {
+		return "", false
+	}
+	sig, ok := db.selectors[[4]byte(sel)]
+	return sig, ok
+}
+
+// Hash returns the signature matching a 32-byte hash.
+func (db *Signatures) Hash(h []byte) (string, bool) {
+	if db == nil || len(h) != 32 {
+		return "", false
+	}
+	sig, ok := db.hashes[[32]byte(h)]
+	return sig, ok
+}
+
+// LoadFile reads signatures from a file. See [Signatures.Load] for the supported
+// formats.
+func (db *Signatures) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := db.Load(data); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// Load adds the signatures in data to the database. The following formats are
+// supported:
+//
+//   - text with one signature per line. Empty lines and lines starting with # are ignored.
+//   - a contract ABI in JSON format, or a JSON object with an "abi" member.
+//   - a JSON array of signature strings.
+//   - a JSON object mapping selectors to signatures or the other way around, as
+//     created by 'solc --hashes' and signature directories.
+func (db *Signatures) Load(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '[' && data[0] != '{') {
+		return db.loadText(data)
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return db.loadJSON(v)
+}
+
+func (db *Signatures) loadText(data []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if err := db.Add(text); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return sc.Err()
+}
+
+func (db *Signatures) loadJSON(v any) error {
+	switch v := v.(type) {
+	case []any:
+		if len(v) > 0 {
+			if _, ok := v[0].(map[string]any); ok {
+				return db.loadABI(v)
+			}
+		}
+		for _, elem := range v {
+			sig, ok := elem.(string)
+			if !ok {
+				return fmt.Errorf("invalid signature list element %v", elem)
+			}
+			if err := db.Add(sig); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case map[string]any:
+		if contractABI, ok := v["abi"]; ok {
+			return db.loadJSON(contractABI)
+		}
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			value := v[key]
+			if s, ok := value.(map[string]any); ok {
+				// Nested object, e.g. "methodIdentifiers" in solc output.
+				if err := db.loadJSON(s); err != nil {
+					return err
+				}
+				continue
+			}
+			// One of key and value is the signature.
+			sig, _ := value.(string)
+			if strings.Contains(key, "(") {
+				sig = key
+			}
+			if err := db.Add(sig); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported JSON signature format")
+	}
+}
+
+func (db *Signatures) loadABI(v []any) error {
+	enc, _ := json.Marshal(v)
+	parsed, err := abi.JSON(bytes.NewReader(enc))
+	if err != nil {
+		return err
+	}
+	for _, m := range parsed.Methods {
+		db.Add(m.Sig)
+	}
+	for _, e := range parsed.Events {
+		db.Add(e.Sig)
+	}
+	for _, e := range parsed.Errors {
+		db.Add(e.Sig)
+	}
+	return nil
+}
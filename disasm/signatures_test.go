@@ -0,0 +1,74 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestSignaturesLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "text",
+			input: "# ERC-20\nfunction transfer(address,uint256)\n\nevent Transfer(address,address,uint256)\n",
+		},
+		{
+			name:  "list",
+			input: `["transfer(address,uint256)", "Transfer(address,address,uint256)"]`,
+		},
+		{
+			name: "abi",
+			input: `[
+  {"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}], "outputs": [{"type": "bool"}]},
+  {"type": "event", "name": "Transfer", "inputs": [{"name": "from", "type": "address", "indexed": true}, {"name": "to", "type": "address", "indexed": true}, {"name": "value", "type": "uint256"}]}
+]`,
+		},
+		{
+			name:  "hashes",
+			input: `{"methodIdentifiers": {"transfer(address,uint256)": "a9059cbb"}, "events": {"0xddf252ad": "Transfer(address,address,uint256)"}}`,
+		},
+	}
+
+	sel := hexutil.MustDecode("0xa9059cbb")
+	topic := hexutil.MustDecode("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	for _, test := range tests {
+		db := NewSignatures()
+		if err := db.Load([]byte(test.input)); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if sig, ok := db.Selector(sel); !ok || sig != "transfer(address,uint256)" {
+			t.Errorf("%s: wrong selector lookup result %q", test.name, sig)
+		}
+		if sig, ok := db.Hash(topic); !ok || sig != "Transfer(address,address,uint256)" {
+			t.Errorf("%s: wrong hash lookup result %q", test.name, sig)
+		}
+	}
+}
+
+func TestSignaturesInvalid(t *testing.T) {
+	for _, input := range []string{"transfer(address", `[1, 2]`, `{"x": "y"}`} {
+		if err := NewSignatures().Load([]byte(input)); err == nil {
+			t.Errorf("no error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/fjl/geas/internal/ast"
+	"github.com/fjl/geas/internal/evm"
+	"github.com/fjl/geas/internal/stack"
+)
+
+const (
+	maxItemNameLength = 20 // derived item names longer than this are abbreviated
+)
+
+// instruction is a decoded instruction in a code segment.
+type instruction struct {
+	pc   int
+	op   *evm.Op // nil for invalid opcodes
+	imm  byte
+	data []byte // PUSH argument
+	end  int    // pc of the next instruction
+}
+
+// instructions decodes the code segments of bytecode, skipping data regions.
+func (d *Disassembler) instructions(bytecode []byte) []instruction {
+	var list []instruction
+	for pc := 0; pc < len(bytecode); pc++ {
+		if r := d.regionAt(pc); r != nil {
+			pc = r.end - 1
+			continue
+		}
+		code := bytecode[:d.codeEnd(pc, len(bytecode))]
+		inst := instruction{pc: pc, op: d.evm.OpByCode(code[pc])}
+		if inst.op != nil {
+			size := immediateSize(inst.op, code[pc:])
+			switch {
+			case inst.op.Push && size < inst.op.PushSize():
+				inst.op = nil // truncated PUSH is printed as #bytes
+			case inst.op.Push:
+				inst.data = code[pc+1 : pc+1+size]
+			case size > 0:
+				inst.imm = code[pc+1]
+			case inst.op.HasImmediate:
+				inst.op = nil // invalid immediate is printed as #bytes
+			}
+			pc += size
+		}
+		inst.end = pc + 1
+		list = append(list, inst)
+	}
+	return list
+}
+
+// stackAnnotator computes stack comments by symbolic execution of the code.
+//
+// The analysis follows the rules of the geas stack checker, so the disassembly passes
+// the check when it is assembled again. Like the checker, it only knows about jumps to
+// labels, i.e. static jumps in symbolic label mode. Code that is not reachable through
+// such jumps starts with an unknown stack, written as [..].
+type stackAnnotator struct {
+	d      *Disassembler
+	cfg    bool // control flow graph mode, see splitBlocks
+	blocks []*stackBlock
+	start  map[int]int // pc -> index of block starting there
+
+	comments      map[int]string     // instruction pc -> comment
+	labelComments map[int]string     // label pc -> comment
+	depths        map[int]StackDepth // instruction pc -> stack depth after it
+}
+
+type stackBlock struct {
+	instrs      []instruction
+	successors  []int
+	jump        int  // index of the static jump target block, -1 if none
+	dynamicJump bool // block ends with a jump to an unknown location
+	fallsThru   bool // execution can continue in the next block
+	entryDepth  StackDepth
+
+	reached   bool
+	predExits map[int][]string // predecessor block index -> exit stack (-1 = initial)
+	predWild  map[int]bool
+	exit      []string
+	exitWild  bool
+}
+
+const initialPred = -1
+
+// computeStackComments runs the stack analysis for the given instructions.
+func (d *Disassembler) computeStackComments(instrs []instruction, cfg bool) *stackAnnotator {
+	a := &stackAnnotator{
+		d:             d,
+		cfg:           cfg,
+		start:         make(map[int]int),
+		comments:      make(map[int]string),
+		labelComments: make(map[int]string),
+		depths:        make(map[int]StackDepth),
+	}
+	a.splitBlocks(instrs)
+	if len(a.blocks) == 0 {
+		return a
+	}
+
+	// Propagate stacks until they are stable.
+	a.blocks[0].reached = true
+	a.blocks[0].predExits = map[int][]string{initialPred: {}}
+	a.blocks[0].predWild = map[int]bool{initialPred: false}
+	var (
+		worklist = []int{0}
+		queued   = map[int]bool{0: true}
+		limit    = 100 * len(a.blocks)
+	)
+	for len(worklist) > 0 && limit > 0 {
+		limit--
+		idx := worklist[0]
+		worklist = worklist[1:]
+		delete(queued, idx)
+
+		blk := a.blocks[idx]
+		s := a.walk(blk, false)
+		blk.exit, blk.exitWild = s.Items(), s.HasWildcard()
+		for _, succ := range blk.successors {
+			if a.blocks[succ].addPredecessor(idx, blk.exit, blk.exitWild) && !queued[succ] {
+				worklist = append(worklist, succ)
+				queued[succ] = true
+			}
+		}
+	}
+
+	// Compute the final comments.
+	for _, blk := range a.blocks {
+		a.walk(blk, true)
+	}
+	return a
+}
+
+// splitBlocks divides the code into basic blocks. The blocks are split just like the
+// stack checker does it: at labels, and after jumps and terminal instructions.
+//
+// In control flow graph mode, the blocks are also split at every JUMPDEST, since it
+// may be the target of a dynamic jump. Undefined instructions and data regions end the
+// block, and any PUSH of a jump target before a jump counts as a static jump.
+func (a *stackAnnotator) splitBlocks(instrs []instruction) {
+	cur := &stackBlock{jump: -1}
+	endBlock := func() {
+		if len(cur.instrs) > 0 {
+			a.blocks = append(a.blocks, cur)
+		}
+		cur = &stackBlock{jump: -1}
+	}
+	for i, inst := range instrs {
+		if a.d.jumpTargets[inst.pc] || a.cfg && inst.op != nil && inst.op.JumpDest {
+			cur.fallsThru = true
+			endBlock()
+		}
+		if a.cfg && i > 0 && instrs[i-1].end < inst.pc {
+			endBlock() // skipped data region
+		}
+		if len(cur.instrs) == 0 {
+			a.start[inst.pc] = len(a.blocks)
+		}
+		cur.instrs = append(cur.instrs, inst)
+		if inst.op == nil {
+			if a.cfg {
+				endBlock()
+			}
+			continue
+		}
+		if !inst.op.Jump && !inst.op.Term {
+			continue
+		}
+		cur.fallsThru = !inst.op.Term && !inst.op.Unconditional
+		if inst.op.Jump {
+			target := -1
+			if prev := i - 1; prev >= 0 && instrs[prev].pc+len(instrs[prev].data)+1 == inst.pc {
+				if t, ok := a.jumpTarget(instrs[prev]); ok {
+					target = t
+				}
+			}
+			if target >= 0 {
+				cur.jump = target // resolved to a block index below
+			} else {
+				cur.dynamicJump = true
+			}
+		}
+		endBlock()
+	}
+	cur.fallsThru = true
+	endBlock()
+
+	for i, blk := range a.blocks {
+		if blk.fallsThru && i+1 < len(a.blocks) {
+			blk.successors = append(blk.successors, i+1)
+		} else {
+			blk.fallsThru = false
+		}
+		if blk.jump >= 0 {
+			blk.jump = a.start[blk.jump]
+			blk.successors = append(blk.successors, blk.jump)
+		}
+	}
+}
+
+// jumpTarget reports whether the instruction is a PUSH of a known jump target, which
+// is used by an immediately following jump.
+func (a *stackAnnotator) jumpTarget(push instruction) (int, bool) {
+	if a.cfg {
+		target, ok := pushValue(push)
+		return target, ok && a.d.jumpTargets[target]
+	}
+	return a.d.labelJump(push)
+}
+
+// labelJump reports whether the instruction is a PUSH which is printed as part of
+// 'jump @label', and returns the label location.
+func (d *Disassembler) labelJump(push instruction) (int, bool) {
+	target, ok := pushValue(push)
+	return target, ok && d.jumpTargets[target] && len(push.data) == minPushSize(target)
+}
+
+// addPredecessor records the exit stack of a predecessor block. It reports whether the
+// entry stack has changed.
+func (blk *stackBlock) addPredecessor(pred int, items []string, wild bool) bool {
+	if blk.predExits == nil {
+		blk.predExits = make(map[int][]string)
+		blk.predWild = make(map[int]bool)
+	}
+	first := !blk.reached
+	blk.reached = true
+	if prev, ok := blk.predExits[pred]; ok && slices.Equal(prev, items) && blk.predWild[pred] == wild {
+		return first
+	}
+	blk.predExits[pred] = items
+	blk.predWild[pred] = wild
+	return true
+}
+
+// entry computes the merged entry stack of a block. Positions where all predecessors
+// agree are confirmed. The other positions are given a generic name, which is declared
+// by the label comment.
+func (blk *stackBlock) entry() (names []string, confirmed []bool, wild bool) {
+	preds := slices.Sorted(maps.Keys(blk.predExits))
+	var base []string
+	depths := make(map[int]bool)
+	for _, p := range preds {
+		items := blk.predExits[p]
+		if base == nil || len(items) < len(base) {
+			base = items
+		}
+		depths[len(items)] = true
+		wild = wild || blk.predWild[p]
+	}
+	names = slices.Clone(base)
+	confirmed = make([]bool, len(names))
+	for i := range names {
+		confirmed[i] = true
+		for _, p := range preds {
+			if blk.predExits[p][i] != base[i] {
+				confirmed[i] = false
+			}
+		}
+	}
+	for i := range names {
+		if !confirmed[i] {
+			names[i] = uniqueName("v", names)
+		}
+	}
+	// Inconsistent depth at the merge point is declared with a wildcard. This also
+	// covers loops which do not have a balanced stack.
+	wild = wild || len(depths) > 1
+	return names, confirmed, wild
+}
+
+// walk applies the instructions of a block to its entry stack.
+func (a *stackAnnotator) walk(blk *stackBlock, record bool) *stack.Stack {
+	var s *stack.Stack
+	first := blk.instrs[0].pc
+	if !blk.reached {
+		s = stack.New([]string{stack.Wildcard}, nil)
+	} else {
+		names, confirmed, wild := blk.entry()
+		if wild {
+			names = append(names, stack.Wildcard)
+		}
+		s = stack.New(names, confirmed)
+	}
+	if record {
+		blk.entryDepth = depthOf(s)
+		if a.d.jumpTargets[first] {
+			a.labelComments[first] = s.String()
+		}
+	}
+	for _, inst := range blk.instrs {
+		if inst.op != nil {
+			comment := a.apply(s, inst)
+			if record {
+				a.comments[inst.pc] = comment
+			}
+		}
+		if record {
+			a.depths[inst.pc] = depthOf(s)
+		}
+	}
+	return s
+}
+
+// apply executes an instruction on the stack, naming its outputs.
+func (a *stackAnnotator) apply(s *stack.Stack, inst instruction) string {
+	var (
+		inNames  = inst.op.StackIn(inst.imm)
+		outNames = inst.op.StackOut(inst.imm)
+		items    = s.Items()
+		wild     = s.HasWildcard() || len(items) < len(inNames)
+	)
+
+	// Name the inputs. Items below the known stack are named after the operation's
+	// input, made unique among the items on the stack.
+	inputs := make([]string, len(inNames))
+	for i := range inputs {
+		if i < len(items) {
+			inputs[i] = items[i]
+		} else {
+			inputs[i] = uniqueName(inNames[i], slices.Concat(items, inputs[len(items):i]))
+		}
+	}
+
+	// Compute the outputs.
+	var comment []string
+	for _, out := range outNames {
+		if j := slices.Index(inNames, out); j >= 0 {
+			comment = append(comment, inputs[j])
+		} else {
+			comment = append(comment, a.itemName(inst, inNames, inputs, out))
+		}
+	}
+	if len(items) > len(inNames) {
+		comment = append(comment, items[len(inNames):]...)
+	}
+	if wild {
+		comment = append(comment, stack.Wildcard)
+	}
+	s.Apply(inst.op, inst.imm, comment)
+	return s.String()
+}
+
+// itemName derives a readable name for an instruction output.
+func (a *stackAnnotator) itemName(inst instruction, inNames, inputs []string, out string) string {
+	if inst.op.Push {
+		if len(inst.data) == 0 {
+			return "0"
+		}
+		if name, ok := a.d.labelRefs[inst.pc]; ok {
+			return "@" + name
+		}
+		if target, ok := pushValue(inst); ok && a.d.jumpTargets[target] {
+			return "@" + a.d.labelName(target)
+		}
+		if len(inst.data) <= 4 {
+			return fmt.Sprintf("%#x", inst.data)
+		}
+		return out
+	}
+
+	// The output name of the operation is an expression of its inputs, e.g. "x+y".
+	// Substitute the input names.
+	var name strings.Builder
+	substituted := false
+	for _, tok := range splitIdentifiers(out) {
+		if j := slices.Index(inNames, tok); j >= 0 {
+			if tok != out && isCompound(inputs[j]) {
+				name.WriteString("(" + inputs[j] + ")")
+			} else {
+				name.WriteString(inputs[j])
+			}
+			substituted = true
+		} else {
+			name.WriteString(tok)
+		}
+	}
+	if !substituted && len(inputs) > 0 {
+		// Use function call notation, e.g. "sload(slot)".
+		name.Reset()
+		name.WriteString(strings.ToLower(inst.op.Name) + "(" + strings.Join(inputs, ",") + ")")
+	}
+	if name.Len() > maxItemNameLength {
+		if substituted {
+			return strings.ToLower(inst.op.Name)
+		}
+		return out
+	}
+	return name.String()
+}
+
+// uniqueName returns a name starting with prefix which is not in taken.
+func uniqueName(prefix string, taken []string) string {
+	name := prefix
+	for n := 2; slices.Contains(taken, name); n++ {
+		name = fmt.Sprintf("%s%d", prefix, n)
+	}
+	return name
+}
+
+// splitIdentifiers splits s into identifiers and the text between them.
+func splitIdentifiers(s string) []string {
+	var (
+		toks  []string
+		start int
+	)
+	isIdent := func(c byte) bool {
+		return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+	for i := 1; i <= len(s); i++ {
+		if i == len(s) || isIdent(s[i]) != isIdent(s[i-1]) {
+			toks = append(toks, s[start:i])
+			start = i
+		}
+	}
+	return toks
+}
+
+// isCompound reports whether a name is an expression with operators.
+func isCompound(name string) bool {
+	return strings.ContainsAny(name, "+-*/%<>=!&|^~")
+}
+
+// setStackComment attaches a stack comment to a statement.
+func setStackComment(st ast.Statement, comment string) {
+	if comment != "" {
+		st.SetComment(&ast.Comment{Text: "; " + comment})
+	}
+}
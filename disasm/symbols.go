@@ -0,0 +1,152 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package disasm
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/ast"
+	"github.com/fjl/geas/internal/evm"
+)
+
+// symbolRegions returns the data regions of the symbol table which fit the bytecode.
+func (d *Disassembler) symbolRegions(codesize int) []dataRegion {
+	data := slices.Clone(d.syms.Data)
+	slices.SortFunc(data, func(a, b asm.SymbolData) int { return cmp.Compare(a.PC, b.PC) })
+	var regions []dataRegion
+	for _, sd := range data {
+		if sd.PC < 0 || sd.Size <= 0 || sd.PC+sd.Size > codesize {
+			continue
+		}
+		if n := len(regions); n > 0 && regions[n-1].end > sd.PC {
+			continue // overlapping
+		}
+		regions = append(regions, dataRegion{start: sd.PC, end: sd.PC + sd.Size, name: sd.Name, text: true})
+	}
+	return regions
+}
+
+// mergeRegions combines detected data regions with the regions of the symbol table.
+// Detected regions are cut where they overlap a symbol region.
+func mergeRegions(detected, symbols []dataRegion) []dataRegion {
+	result := slices.Clone(symbols)
+	for _, r := range detected {
+		for _, s := range symbols {
+			if s.end <= r.start || s.start >= r.end {
+				continue
+			}
+			if s.start > r.start {
+				result = append(result, dataRegion{start: r.start, end: s.start, comments: r.comments, text: r.text})
+			}
+			r.start, r.comments = s.end, nil
+		}
+		if r.start < r.end {
+			result = append(result, r)
+		}
+	}
+	slices.SortFunc(result, func(a, b dataRegion) int { return cmp.Compare(a.start, b.start) })
+	return result
+}
+
+// resolveSymbols matches the labels and label references of the symbol table against
+// the decoded instructions. Symbols which do not fit the code are ignored.
+//
+// Labels at a JUMPDEST become jump targets. Other labels are printed as dotted labels,
+// which is also done for non-dotted labels when the code does not have a JUMPDEST at
+// their location.
+func (d *Disassembler) resolveSymbols(instrs []instruction, codesize int) {
+	d.labelNames = make(map[int]string)
+	d.dottedLabels = make(map[int][]string)
+	d.labelRefs = make(map[int]string)
+	if d.jumpTargets == nil {
+		d.jumpTargets = make(map[int]bool)
+	}
+
+	byPC := make(map[int]instruction, len(instrs))
+	for _, inst := range instrs {
+		byPC[inst.pc] = inst
+	}
+	regionPC := make(map[string]int)
+	for _, r := range d.regions {
+		if r.name != "" {
+			regionPC[r.name] = r.start
+		}
+	}
+	labelPC := make(map[string]int)
+	for _, l := range d.syms.Labels {
+		if _, dup := labelPC[l.Name]; dup || l.Name == "" {
+			continue
+		}
+		if pc, ok := regionPC[l.Name]; ok && pc != l.PC {
+			continue // conflicts with the name of a #bytes region
+		}
+		inst, isInstr := byPC[l.PC]
+		r := d.regionAt(l.PC)
+		switch {
+		case !l.Dotted && isInstr && inst.op != nil && inst.op.JumpDest && d.labelNames[l.PC] == "":
+			d.labelNames[l.PC] = l.Name
+			d.jumpTargets[l.PC] = true
+		case r != nil && r.name == l.Name:
+			// The label is printed as the name of the region.
+		case isInstr || r != nil || l.PC == codesize:
+			d.dottedLabels[l.PC] = append(d.dottedLabels[l.PC], l.Name)
+		default:
+			continue
+		}
+		labelPC[l.Name] = l.PC
+	}
+
+	for _, ref := range d.syms.Refs {
+		target, ok := labelPC[ref.Label]
+		if !ok {
+			continue
+		}
+		if v, ok := pushValue(byPC[ref.PC]); ok && v == target {
+			d.labelRefs[ref.PC] = ref.Label
+		}
+	}
+}
+
+// labelName returns the name of the label at a jump target.
+func (d *Disassembler) labelName(pc int) string {
+	if name, ok := d.labelNames[pc]; ok {
+		return name
+	}
+	return "L_" + d.pcString(pc)
+}
+
+// addDottedLabels adds the dotted labels of the symbol table at pc.
+func (d *Disassembler) addDottedLabels(out *docBuilder, pc int) {
+	for _, name := range d.dottedLabels[pc] {
+		out.add(&ast.LabelDef{Ident: name, Dotted: true})
+	}
+}
+
+// addLabelPush adds a PUSH instruction whose argument is a label reference.
+// The label reference was checked by resolveSymbols, so code holds the complete PUSH.
+func (d *Disassembler) addLabelPush(out *docBuilder, push *evm.Op, code []byte, name string) (ast.Statement, int) {
+	size := push.PushSize()
+	target, _ := pushValue(instruction{op: push, data: code[1 : 1+size]})
+	st := d.opcode(push, &ast.LabelRefExpr{Ident: name})
+	if size == minPushSize(target) {
+		// The assembler chooses the same size for 'push @label'.
+		st.Op = d.opName("push")
+	}
+	return out.add(st), size
+}
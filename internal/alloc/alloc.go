@@ -0,0 +1,215 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package alloc creates genesis allocations of contracts.
+//
+// The input is a YAML manifest which maps addresses to accounts:
+//
+//	target: prague
+//	accounts:
+//	  0x000F3df6D732807Ef1319fB7B8bB8522d0Beac02:
+//	    source: 4788asm.eas
+//	    nonce: 1
+//	  0x00000000000000000000000000000000000c0de1:
+//	    constructor: erc20_ctor.eas
+//	    caller: 0x0000000000000000000000000000000000001234
+//	    balance: 1000000000000000000
+//
+// The code of an account is given by one of the fields:
+//
+//   - source: a geas file containing the runtime code
+//   - code: the runtime code as hex
+//   - constructor: a geas file containing initcode
+//
+// Initcode is executed at the account address, and the returned code becomes the code
+// of the account. Storage written by the initcode is included in the allocation.
+package alloc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/loader"
+	"github.com/fjl/geas/internal/testfile"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the definition of a genesis allocation.
+type Manifest struct {
+	Path     string              `yaml:"-"`
+	Target   string              `yaml:"target,omitempty"` // default instruction set
+	Accounts map[string]*Account `yaml:"accounts"`
+}
+
+// Account is an account of the allocation.
+type Account struct {
+	Source      string            `yaml:"source,omitempty"`
+	Code        string            `yaml:"code,omitempty"`
+	Constructor string            `yaml:"constructor,omitempty"`
+	Caller      string            `yaml:"caller,omitempty"` // caller of the constructor
+	Balance     string            `yaml:"balance,omitempty"`
+	Nonce       uint64            `yaml:"nonce,omitempty"`
+	Storage     map[string]string `yaml:"storage,omitempty"`
+}
+
+// Load reads a manifest.
+func Load(fsys fs.FS, file string) (*Manifest, error) {
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(m); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	m.Path = file
+	return m, nil
+}
+
+// Build creates the allocation. Source files are read from fsys.
+func (m *Manifest) Build(fsys fs.FS) (types.GenesisAlloc, error) {
+	alloc := make(types.GenesisAlloc)
+	for _, addrText := range slices.Sorted(maps.Keys(m.Accounts)) {
+		if !common.IsHexAddress(addrText) {
+			return nil, fmt.Errorf("invalid address %q", addrText)
+		}
+		addr := common.HexToAddress(addrText)
+		if _, ok := alloc[addr]; ok {
+			return nil, fmt.Errorf("duplicate account %v", addr)
+		}
+		acc, err := m.account(fsys, addr, m.Accounts[addrText])
+		if err != nil {
+			return nil, fmt.Errorf("account %v: %v", addr, err)
+		}
+		alloc[addr] = acc
+	}
+	return alloc, nil
+}
+
+func (m *Manifest) account(fsys fs.FS, addr common.Address, spec *Account) (types.Account, error) {
+	if spec == nil {
+		spec = new(Account)
+	}
+	acc := types.Account{Balance: new(big.Int), Nonce: spec.Nonce}
+	if spec.Balance != "" {
+		v, err := testfile.ParseWord(spec.Balance)
+		if err != nil {
+			return acc, fmt.Errorf("invalid balance: %v", err)
+		}
+		acc.Balance = v.Big()
+	}
+	storage, err := testfile.ParseStorage(spec.Storage)
+	if err != nil {
+		return acc, err
+	}
+	if spec.Caller != "" && spec.Constructor == "" {
+		return acc, errors.New("caller is only allowed with constructor")
+	}
+
+	var codeFields int
+	for _, f := range []string{spec.Source, spec.Code, spec.Constructor} {
+		if f != "" {
+			codeFields++
+		}
+	}
+	if codeFields > 1 {
+		return acc, errors.New("only one of source, code and constructor can be set")
+	}
+	switch {
+	case spec.Source != "":
+		if acc.Code, _, err = m.compile(fsys, spec.Source); err != nil {
+			return acc, err
+		}
+	case spec.Code != "":
+		if acc.Code, err = testfile.ParseHex(spec.Code); err != nil {
+			return acc, fmt.Errorf("invalid code: %v", err)
+		}
+	case spec.Constructor != "":
+		if acc.Code, err = m.construct(fsys, addr, spec, storage); err != nil {
+			return acc, err
+		}
+	}
+
+	for slot, value := range storage {
+		if value != (common.Hash{}) {
+			if acc.Storage == nil {
+				acc.Storage = make(map[common.Hash]common.Hash)
+			}
+			acc.Storage[slot] = value
+		}
+	}
+	return acc, nil
+}
+
+// construct runs the initcode of an account. The returned runtime code is returned,
+// and storage is updated with the writes of the initcode.
+func (m *Manifest) construct(fsys fs.FS, addr common.Address, spec *Account, storage map[common.Hash]common.Hash) ([]byte, error) {
+	initcode, fork, err := m.compile(fsys, spec.Constructor)
+	if err != nil {
+		return nil, err
+	}
+	cfg := evmrun.Config{Fork: fork, Address: addr, Storage: storage}
+	if spec.Caller != "" {
+		if !common.IsHexAddress(spec.Caller) {
+			return nil, fmt.Errorf("invalid caller %q", spec.Caller)
+		}
+		cfg.Caller = common.HexToAddress(spec.Caller)
+	}
+	res, err := evmrun.Run(initcode, cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case res.Reverted() && res.RevertReason != "":
+		return nil, fmt.Errorf("constructor reverted: %s", res.RevertReason)
+	case res.Err != nil:
+		return nil, fmt.Errorf("constructor failed: %v", res.Err)
+	}
+	for _, sc := range res.Storage {
+		if sc.Address == addr {
+			storage[sc.Slot] = sc.After
+		}
+	}
+	return res.ReturnData, nil
+}
+
+// compile compiles a source file. It also returns the instruction set of the program.
+func (m *Manifest) compile(fsys fs.FS, source string) ([]byte, string, error) {
+	file, err := loader.ResolveRelative(m.Path, source)
+	if err != nil {
+		return nil, "", err
+	}
+	c := asm.New(fsys)
+	if m.Target != "" {
+		c.SetDefaultFork(m.Target)
+	}
+	code := c.CompileFile(file)
+	if c.Failed() {
+		return nil, "", errors.Join(c.Errors()...)
+	}
+	return code, c.DebugInfo().Fork, nil
+}
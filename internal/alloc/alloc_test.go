@@ -0,0 +1,127 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package alloc
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"math/big"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var testFS = fstest.MapFS{
+	"devnet/runtime.eas": {Data: []byte(`
+	push 0
+	sload
+	push 0
+	mstore
+	push 32
+	push 0
+	return
+`)},
+	"devnet/ctor.eas": {Data: []byte(`
+	push 7
+	caller
+	sstore
+	push len(code)          ; [size]
+	dup1                    ; [size, size]
+	push @code              ; [start, size, size]
+	push 0                  ; [0, start, size, size]
+	codecopy                ; [size]
+	push 0                  ; [0, size]
+	return                  ; []
+
+#bytes code: assemble("runtime.eas")
+`)},
+	"devnet/alloc.yaml": {Data: []byte(`
+target: cancun
+accounts:
+  0x00000000000000000000000000000000000000aa:
+    source: runtime.eas
+    nonce: 1
+    storage: {0: 5}
+  0x00000000000000000000000000000000000000bb:
+    constructor: ctor.eas
+    caller: 0x0000000000000000000000000000000000001234
+    storage: {1: 2}
+  0x00000000000000000000000000000000000000cc:
+    balance: 0x10
+`)},
+}
+
+func TestBuild(t *testing.T) {
+	m, err := Load(testFS, "devnet/alloc.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alloc, err := m.Build(testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runtime := common.FromHex("0x5f545f5260205ff3")
+	aa := alloc[common.HexToAddress("0xaa")]
+	if !bytes.Equal(aa.Code, runtime) || aa.Nonce != 1 || aa.Storage[word(0)] != word(5) {
+		t.Errorf("wrong account aa: %+v", aa)
+	}
+	bb := alloc[common.HexToAddress("0xbb")]
+	if !bytes.Equal(bb.Code, runtime) {
+		t.Errorf("wrong code for account bb: %x", bb.Code)
+	}
+	wantStorage := map[common.Hash]common.Hash{word(1): word(2), word(0x1234): word(7)}
+	if !maps.Equal(bb.Storage, wantStorage) {
+		t.Errorf("wrong storage for account bb: %v, want %v", bb.Storage, wantStorage)
+	}
+	if cc := alloc[common.HexToAddress("0xcc")]; cc.Balance.Int64() != 16 || cc.Code != nil {
+		t.Errorf("wrong account cc: %+v", cc)
+	}
+
+	// Check the JSON encoding can be read back.
+	enc, err := json.Marshal(alloc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec types.GenesisAlloc
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if len(dec) != 3 {
+		t.Fatalf("wrong decoded alloc: %s", enc)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	m := &Manifest{Path: "devnet/alloc.yaml", Accounts: map[string]*Account{
+		"0xaa": {Source: "runtime.eas", Code: "0x00"},
+	}}
+	if _, err := m.Build(testFS); err == nil {
+		t.Error("expected error for account with source and code")
+	}
+	m.Accounts = map[string]*Account{"0xaa": {Constructor: "runtime.eas", Caller: "0x1234"}}
+	if _, err := m.Build(testFS); err == nil {
+		t.Error("expected error for invalid caller")
+	}
+}
+
+func word(v int64) common.Hash {
+	return common.BigToHash(new(big.Int).SetInt64(v))
+}
@@ -68,6 +68,10 @@ type Statement interface {
 	// StartsBlock returns true when the source code contains one or more blank lines
 	// before the statement.
 	StartsBlock() bool
+
+	// SetComment and SetStartsBlock modify the formatting of the statement.
+	SetComment(*Comment)
+	SetStartsBlock(bool)
 }
 
 // stbase is embedded into all statement types.
@@ -99,6 +103,17 @@ func (st *stbase) StartsBlock() bool {
 	return st.startsBlock
 }
 
+// SetComment attaches a line comment to the statement. This is for building documents
+// in code, the parser assigns comments automatically.
+func (st *stbase) SetComment(c *Comment) {
+	st.comment = c
+}
+
+// SetStartsBlock sets whether the statement is preceded by a blank line.
+func (st *stbase) SetStartsBlock(on bool) {
+	st.startsBlock = on
+}
+
 // toplevel statement types
 type (
 	Opcode struct {
@@ -123,6 +138,10 @@ type (
 		stbase
 		PC   uint64 // the program counter value, parsed as hex
 		Text string // original text of the label, without the ':'
+
+		// Inline is set when the label is followed by another statement on the same
+		// line, like in '0002: push1 0x40'.
+		Inline bool
 	}
 
 	InstructionMacroCall struct {
@@ -269,3 +288,79 @@ func (st *Comment) IsStackComment() bool {
 func (st *Comment) InnerText() string {
 	return strings.TrimSpace(strings.TrimLeft(st.Text, ";"))
 }
+
+// Clone creates a deep copy of the document. All statements, including the bodies of
+// instruction macros, are copied and attached to the new document. Expressions and
+// comments are immutable, and are shared with the original document.
+func (doc *Document) Clone() *Document {
+	return doc.clone(doc.Parent)
+}
+
+func (doc *Document) clone(parent *Document) *Document {
+	c := &Document{
+		File:       doc.File,
+		Parent:     parent,
+		Creation:   doc.Creation,
+		Statements: make([]Statement, len(doc.Statements)),
+	}
+	for i, st := range doc.Statements {
+		c.Statements[i] = cloneStatement(st, c)
+	}
+	return c
+}
+
+func cloneStatement(st Statement, doc *Document) Statement {
+	switch st := st.(type) {
+	case *Opcode:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *LabelDef:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *PCLabel:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *InstructionMacroCall:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *Include:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *Assemble:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *Pragma:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *Bytes:
+		cpy := *st
+		cpy.src = doc
+		if st.Label != nil {
+			cpy.Label = cloneStatement(st.Label, doc).(*LabelDef)
+		}
+		return &cpy
+	case *Comment:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *ExpressionMacroDef:
+		cpy := *st
+		cpy.src = doc
+		return &cpy
+	case *InstructionMacroDef:
+		cpy := *st
+		cpy.src = doc
+		cpy.Body = st.Body.clone(doc)
+		cpy.Body.Creation = &cpy
+		return &cpy
+	default:
+		panic(fmt.Sprintf("unhandled statement type %T", st))
+	}
+}
@@ -16,7 +16,12 @@
 
 package ast
 
-import "github.com/fjl/geas/internal/lzint"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fjl/geas/internal/lzint"
+)
 
 type Expr interface {
 	Position() Position
@@ -97,6 +102,39 @@ func MakeString(v string) *LiteralExpr {
 	}
 }
 
+// MakeStringLiteral creates a string literal with the given content. Unlike MakeString,
+// the literal text is escaped, so it can be printed as valid source.
+func MakeStringLiteral(content []byte) *LiteralExpr {
+	return &LiteralExpr{
+		text:   escapeStringText(content),
+		string: true,
+		value:  lzint.FromBytes(content),
+	}
+}
+
+// escapeStringText is the inverse of parseStringText.
+func escapeStringText(b []byte) string {
+	var s strings.Builder
+	for _, c := range b {
+		switch {
+		case c == '\\' || c == '"':
+			s.WriteByte('\\')
+			s.WriteByte(c)
+		case c == '\n':
+			s.WriteString(`\n`)
+		case c == '\r':
+			s.WriteString(`\r`)
+		case c == '\t':
+			s.WriteString(`\t`)
+		case c < 0x20 || c > 0x7e:
+			fmt.Fprintf(&s, `\x%02x`, c)
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return s.String()
+}
+
 // Value returns the parsed value of the literal.
 func (e *LiteralExpr) Value() *lzint.Value {
 	return e.value
@@ -235,6 +235,9 @@ func parseStatement(p *Parser) (done bool) {
 			st.base().comment = p.makeComment(tok)
 		default:
 			// There's another statement on the same line, the next call will handle it.
+			if l, ok := st.(*PCLabel); ok {
+				l.Inline = true
+			}
 			p.unread(tok)
 		}
 	}
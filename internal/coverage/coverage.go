@@ -0,0 +1,273 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package coverage records which parts of a program are executed.
+package coverage
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evm"
+)
+
+// Profile contains the execution counts of instructions of a program.
+type Profile struct {
+	Info     *asm.DebugInfo
+	Counts   map[int]uint64  // execution count by PC
+	Branches map[int]*Branch // JUMPI outcomes by PC
+}
+
+// Branch counts the outcomes of a conditional jump.
+type Branch struct {
+	Taken    uint64
+	NotTaken uint64
+}
+
+// New creates an empty profile.
+func New(info *asm.DebugInfo) *Profile {
+	return &Profile{
+		Info:     info,
+		Counts:   make(map[int]uint64),
+		Branches: make(map[int]*Branch),
+	}
+}
+
+// Hooks returns tracer hooks which record executed instructions of the toplevel call
+// frame into the profile. The hooks can be used for any number of executions.
+func (p *Profile) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+			if depth != 1 {
+				return
+			}
+			p.Counts[int(pc)]++
+			if vm.OpCode(op) != vm.JUMPI {
+				return
+			}
+			b := p.Branches[int(pc)]
+			if b == nil {
+				b = new(Branch)
+				p.Branches[int(pc)] = b
+			}
+			if stack := scope.StackData(); len(stack) >= 2 && !stack[len(stack)-2].IsZero() {
+				b.Taken++
+			} else {
+				b.NotTaken++
+			}
+		},
+	}
+}
+
+// Report is the coverage of source files, aggregated over one or more profiles.
+type Report struct {
+	instrs map[instrKey]*instrCoverage
+}
+
+// instrKey identifies an instruction across compilations of a program. Instructions are
+// identified by their source line and macro expansion chain. As a line can produce
+// multiple instructions, they are numbered as well.
+type instrKey struct {
+	file  string
+	line  int
+	chain string
+	n     int
+}
+
+type instrCoverage struct {
+	count      uint64
+	blockStart bool
+	branch     *Branch // set for JUMPI
+}
+
+// NewReport creates an empty report.
+func NewReport() *Report {
+	return &Report{instrs: make(map[instrKey]*instrCoverage)}
+}
+
+// Add adds the counts of a profile to the report.
+func (r *Report) Add(p *Profile) {
+	is := evm.FindInstructionSet(p.Info.Fork)
+	var (
+		ordinal    = make(map[instrKey]int)
+		blockStart = true
+	)
+	for _, di := range p.Info.Instructions {
+		if di.Op == "#bytes" {
+			blockStart = true
+			continue
+		}
+		key := instrKey{file: di.Pos.File, line: di.Pos.Line, chain: chainKey(di.Expansion)}
+		n := ordinal[key]
+		ordinal[key] = n + 1
+		key.n = n
+
+		ic := r.instrs[key]
+		if ic == nil {
+			ic = new(instrCoverage)
+			r.instrs[key] = ic
+		}
+		ic.count += p.Counts[di.PC]
+		if di.Op == "JUMPDEST" {
+			blockStart = true
+		}
+		ic.blockStart = ic.blockStart || blockStart
+		if b := p.Branches[di.PC]; b != nil || di.Op == "JUMPI" {
+			if ic.branch == nil {
+				ic.branch = new(Branch)
+			}
+			if b != nil {
+				ic.branch.Taken += b.Taken
+				ic.branch.NotTaken += b.NotTaken
+			}
+		}
+
+		// The next instruction starts a block if this one ends it.
+		op := is.OpByName(di.Op)
+		blockStart = op != nil && (op.Term || op.Jump)
+	}
+}
+
+func chainKey(chain []asm.ExpansionFrame) string {
+	var b strings.Builder
+	for _, f := range chain {
+		b.WriteString(f.Pos.String())
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// Line is the coverage of a source line.
+type Line struct {
+	File  string
+	Line  int
+	Count uint64 // number of times the line was executed
+
+	// Instructions is the number of instructions generated by the line,
+	// and Covered is the number of those that were executed.
+	Instructions int
+	Covered      int
+
+	Branches []Branch // for JUMPI instructions on the line
+}
+
+// branchesCovered reports whether all conditional jumps on the line were
+// executed in both directions.
+func (l *Line) branchesCovered() bool {
+	for _, br := range l.Branches {
+		if br.Taken == 0 || br.NotTaken == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Lines returns the coverage of all source lines that generate instructions,
+// ordered by file and line.
+func (r *Report) Lines() []Line {
+	type lineKey struct {
+		file string
+		line int
+	}
+	lines := make(map[lineKey]*Line)
+	instanceCounts := make(map[instrKey]uint64) // max count by line instance
+	for _, key := range r.sortedKeys() {
+		ic := r.instrs[key]
+		lk := lineKey{key.file, key.line}
+		l := lines[lk]
+		if l == nil {
+			l = &Line{File: key.file, Line: key.line}
+			lines[lk] = l
+		}
+		l.Instructions++
+		if ic.count > 0 {
+			l.Covered++
+		}
+		if ic.branch != nil {
+			l.Branches = append(l.Branches, *ic.branch)
+		}
+		ik := instrKey{file: key.file, line: key.line, chain: key.chain}
+		instanceCounts[ik] = max(instanceCounts[ik], ic.count)
+	}
+	for ik, count := range instanceCounts {
+		lines[lineKey{ik.file, ik.line}].Count += count
+	}
+	result := make([]Line, 0, len(lines))
+	for _, l := range lines {
+		result = append(result, *l)
+	}
+	slices.SortFunc(result, func(a, b Line) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	return result
+}
+
+func (r *Report) sortedKeys() []instrKey {
+	return slices.SortedFunc(maps.Keys(r.instrs), func(a, b instrKey) int {
+		return cmp.Or(
+			cmp.Compare(a.file, b.file),
+			cmp.Compare(a.line, b.line),
+			cmp.Compare(a.chain, b.chain),
+			cmp.Compare(a.n, b.n),
+		)
+	})
+}
+
+// Summary contains coverage totals.
+type Summary struct {
+	Lines, LinesHit               int
+	Instructions, InstructionsHit int
+	Blocks, BlocksHit             int
+	Branches, BranchesHit         int // two branches per JUMPI
+}
+
+// Summary computes the coverage totals.
+func (r *Report) Summary() Summary {
+	var s Summary
+	for _, ic := range r.instrs {
+		s.Instructions++
+		if ic.count > 0 {
+			s.InstructionsHit++
+		}
+		if ic.blockStart {
+			s.Blocks++
+			if ic.count > 0 {
+				s.BlocksHit++
+			}
+		}
+		if ic.branch != nil {
+			s.Branches += 2
+			if ic.branch.Taken > 0 {
+				s.BranchesHit++
+			}
+			if ic.branch.NotTaken > 0 {
+				s.BranchesHit++
+			}
+		}
+	}
+	for _, l := range r.Lines() {
+		s.Lines++
+		if l.Count > 0 {
+			s.LinesHit++
+		}
+	}
+	return s
+}
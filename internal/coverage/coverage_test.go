@@ -0,0 +1,142 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+const testProgram = `push 0
+calldataload        ; [x]
+jumpi @nonzero      ; []
+push 1
+push 0
+sstore
+stop
+nonzero:
+push 2
+push 0
+sstore
+stop
+unused:
+push 3
+pop
+`
+
+func runCoverage(t *testing.T, inputs ...[]byte) *Report {
+	t.Helper()
+	c := asm.New(nil)
+	code := c.CompileString(testProgram)
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	report := NewReport()
+	for _, input := range inputs {
+		prof := New(c.DebugInfo())
+		_, err := evmrun.Run(code, evmrun.Config{Input: input, Tracer: prof.Hooks()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		report.Add(prof)
+	}
+	return report
+}
+
+func TestLines(t *testing.T) {
+	report := runCoverage(t, nil, nil, []byte{1})
+
+	var want []Line
+	for n, count := range []uint64{3, 3, 3, 2, 2, 2, 2, 1, 1, 1, 1, 1, 0, 0, 0} {
+		l := Line{Line: n + 1, Count: count, Instructions: 1}
+		if count > 0 {
+			l.Covered = 1
+		}
+		want = append(want, l)
+	}
+	want[2].Instructions = 2 // PUSH + JUMPI
+	want[2].Covered = 2
+	want[2].Branches = []Branch{{Taken: 1, NotTaken: 2}}
+
+	if got := report.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong lines")
+		for _, l := range got {
+			t.Logf("%+v", l)
+		}
+	}
+
+	wantSummary := Summary{
+		Lines: 15, LinesHit: 12,
+		Instructions: 16, InstructionsHit: 13,
+		Blocks: 4, BlocksHit: 3,
+		Branches: 2, BranchesHit: 2,
+	}
+	if s := report.Summary(); s != wantSummary {
+		t.Errorf("wrong summary %+v", s)
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	report := runCoverage(t, nil)
+
+	var b strings.Builder
+	if err := report.WriteLCOV(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"DA:3,1\nBRDA:3,0,0,1\nBRDA:3,0,1,0\n",
+		"DA:9,0\n",
+		"BRF:2\nBRH:1\nLF:15\nLH:7\nend_of_record\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(out)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	report := runCoverage(t, nil)
+	source := func(file string) []string {
+		return strings.Split(testProgram, "\n")
+	}
+
+	var b strings.Builder
+	if err := report.WriteText(&b, source); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"       1     3 | jumpi @nonzero      ; []    [jump 0, no jump 1]\n",
+		"   #####     9 | push 2\n",
+		"lines: 7/15 (46.7%), blocks: 2/4 (50.0%), branches: 1/2 (50.0%)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(out)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// WriteLCOV writes the report in LCOV tracefile format.
+func (r *Report) WriteLCOV(w io.Writer) error {
+	var b strings.Builder
+	lines := r.Lines()
+	for i := 0; i < len(lines); {
+		file := lines[i].File
+		var lf, lh, brf, brh int
+		fmt.Fprintf(&b, "TN:\nSF:%s\n", file)
+		for ; i < len(lines) && lines[i].File == file; i++ {
+			l := lines[i]
+			fmt.Fprintf(&b, "DA:%d,%d\n", l.Line, l.Count)
+			lf++
+			if l.Count > 0 {
+				lh++
+			}
+			for j, br := range l.Branches {
+				// Branch 0 is the fall-through, branch 1 is the jump.
+				if br.Taken+br.NotTaken == 0 {
+					fmt.Fprintf(&b, "BRDA:%d,%d,0,-\nBRDA:%d,%d,1,-\n", l.Line, j, l.Line, j)
+				} else {
+					fmt.Fprintf(&b, "BRDA:%d,%d,0,%d\nBRDA:%d,%d,1,%d\n", l.Line, j, br.NotTaken, l.Line, j, br.Taken)
+				}
+				brf += 2
+				brh += int(min(br.NotTaken, 1) + min(br.Taken, 1))
+			}
+		}
+		fmt.Fprintf(&b, "BRF:%d\nBRH:%d\nLF:%d\nLH:%d\nend_of_record\n", brf, brh, lf, lh)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SourceFunc returns the lines of a source file.
+// It returns nil if the file content is not available.
+type SourceFunc func(file string) []string
+
+// WriteText writes an annotated listing of the source files. Each line is prefixed by
+// its execution count. Lines which were not executed are marked with #####.
+func (r *Report) WriteText(w io.Writer, source SourceFunc) error {
+	var b strings.Builder
+	for _, f := range r.files(source) {
+		fmt.Fprintf(&b, "%s:\n", f.Name)
+		for _, l := range f.Lines {
+			fmt.Fprintf(&b, "%8s  %4d | %s", l.CountText(), l.Number, l.Text)
+			if note := l.BranchText(); note != "" {
+				fmt.Fprintf(&b, "    [%s]", note)
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	s := r.Summary()
+	fmt.Fprintf(&b, "lines: %s, blocks: %s, branches: %s\n",
+		percent(s.LinesHit, s.Lines), percent(s.BlocksHit, s.Blocks), percent(s.BranchesHit, s.Branches))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func percent(hit, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", hit, total, float64(hit)/float64(total)*100)
+}
+
+// WriteHTML writes a HTML page showing the coverage of source files.
+func (r *Report) WriteHTML(w io.Writer, source SourceFunc) error {
+	s := r.Summary()
+	return htmlTemplate.Execute(w, map[string]any{
+		"Files":    r.files(source),
+		"Lines":    percent(s.LinesHit, s.Lines),
+		"Blocks":   percent(s.BlocksHit, s.Blocks),
+		"Branches": percent(s.BranchesHit, s.Branches),
+	})
+}
+
+// sourceFile is a source file annotated with coverage.
+type sourceFile struct {
+	Name  string
+	Lines []sourceLine
+}
+
+type sourceLine struct {
+	Number int
+	Text   string
+	Cov    *Line // nil for lines without instructions
+}
+
+// Class returns the CSS class of the line.
+func (l sourceLine) Class() string {
+	switch {
+	case l.Cov == nil:
+		return ""
+	case l.Cov.Count == 0:
+		return "miss"
+	case l.Cov.Covered < l.Cov.Instructions || !l.Cov.branchesCovered():
+		return "partial"
+	default:
+		return "hit"
+	}
+}
+
+// CountText returns the execution count column.
+func (l sourceLine) CountText() string {
+	switch {
+	case l.Cov == nil:
+		return "-"
+	case l.Cov.Count == 0:
+		return "#####"
+	default:
+		return fmt.Sprint(l.Cov.Count)
+	}
+}
+
+// BranchText describes the outcomes of conditional jumps on the line.
+func (l sourceLine) BranchText() string {
+	if l.Cov == nil || len(l.Cov.Branches) == 0 {
+		return ""
+	}
+	parts := make([]string, len(l.Cov.Branches))
+	for i, br := range l.Cov.Branches {
+		parts[i] = fmt.Sprintf("jump %d, no jump %d", br.Taken, br.NotTaken)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// files returns the annotated source files. If the content of a file is not available,
+// only lines with instructions are included. The source function may be nil.
+func (r *Report) files(source SourceFunc) []sourceFile {
+	var files []sourceFile
+	lines := r.Lines()
+	for i := 0; i < len(lines); {
+		f := sourceFile{Name: lines[i].File}
+		j := i
+		for j < len(lines) && lines[j].File == f.Name {
+			j++
+		}
+		cov := lines[i:j]
+		var text []string
+		if source != nil {
+			text = source(f.Name)
+		}
+		if len(text) > 0 && text[len(text)-1] == "" {
+			text = text[:len(text)-1]
+		}
+		if len(text) == 0 {
+			for k := range cov {
+				f.Lines = append(f.Lines, sourceLine{Number: cov[k].Line, Cov: &cov[k]})
+			}
+		} else {
+			for n, t := range text {
+				sl := sourceLine{Number: n + 1, Text: strings.ReplaceAll(t, "\t", "    ")}
+				if len(cov) > 0 && cov[0].Line == n+1 {
+					sl.Cov = &cov[0]
+					cov = cov[1:]
+				}
+				f.Lines = append(f.Lines, sl)
+			}
+		}
+		files = append(files, f)
+		i = j
+	}
+	return files
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>geas coverage</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; font-family: monospace; white-space: pre; }
+td { padding: 0 0.5em; }
+td.count, td.num { text-align: right; color: #666; }
+tr.hit { background: #dfd; }
+tr.miss { background: #fdd; }
+tr.partial { background: #ffd; }
+td.branch { color: #666; }
+</style>
+</head>
+<body>
+<p>lines: {{.Lines}}, blocks: {{.Blocks}}, branches: {{.Branches}}</p>
+{{range .Files}}
+<h2>{{.Name}}</h2>
+<table>
+{{- range .Lines}}
+<tr class="{{.Class}}"><td class="num">{{.Number}}</td><td class="count">{{.CountText}}</td><td>{{.Text}}</td><td class="branch">{{.BranchText}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,348 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package debugger implements stepwise execution of geas programs.
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/holiman/uint256"
+)
+
+// Session is a debugging session. The program runs in a background goroutine, and is
+// paused before each instruction of the toplevel call frame.
+//
+// Session methods must not be called concurrently.
+type Session struct {
+	info     *asm.DebugInfo
+	mainFile string
+	addr     common.Address
+	state    *state.StateDB
+
+	pause    chan *Frame
+	resume   chan struct{}
+	done     chan outcome
+	detached atomic.Bool
+
+	cur         *Frame
+	result      *evmrun.Result
+	err         error
+	steps       int
+	lastInstr   *asm.DebugInstruction
+	slots       map[common.Hash]struct{}
+	breakpoints []*Breakpoint
+	nextBP      int
+}
+
+type outcome struct {
+	res *evmrun.Result
+	err error
+}
+
+// Frame is the state of execution at an instruction.
+type Frame struct {
+	PC     uint64
+	Op     vm.OpCode
+	Gas    uint64 // remaining gas
+	Step   int    // number of instructions executed before this one
+	Stack  []uint256.Int
+	Memory []byte
+
+	// Instr is the instruction at PC. It is nil if PC is outside of the program.
+	Instr *asm.DebugInstruction
+
+	// StackNames are the stack item names declared by the stack comment of the
+	// previously executed instruction. Like Stack, it is ordered top first.
+	StackNames []string
+}
+
+// Breakpoint is a set of program locations where execution stops.
+type Breakpoint struct {
+	ID   int
+	Spec string
+	PCs  []int
+}
+
+// Start begins execution of code. The mainFile is the source file of the program, and is
+// used to resolve breakpoints given as a plain line number.
+func Start(code []byte, info *asm.DebugInfo, mainFile string, cfg evmrun.Config) *Session {
+	s := &Session{
+		info:     info,
+		mainFile: mainFile,
+		addr:     cfg.Address,
+		state:    cfg.State,
+		pause:    make(chan *Frame),
+		resume:   make(chan struct{}),
+		done:     make(chan outcome, 1),
+		slots:    make(map[common.Hash]struct{}),
+	}
+	if s.addr == (common.Address{}) {
+		s.addr = evmrun.DefaultAddress
+	}
+	if s.state == nil {
+		s.state, _ = state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		cfg.State = s.state
+	}
+	for slot := range cfg.Storage {
+		s.slots[slot] = struct{}{}
+	}
+	cfg.Tracer = &tracing.Hooks{OnOpcode: s.onOpcode}
+
+	go func() {
+		res, err := evmrun.Run(code, cfg)
+		s.done <- outcome{res, err}
+	}()
+	s.wait()
+	return s
+}
+
+// onOpcode runs on the EVM goroutine.
+func (s *Session) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if depth != 1 || s.detached.Load() {
+		return
+	}
+	data := scope.StackData()
+	f := &Frame{
+		PC:     pc,
+		Op:     vm.OpCode(op),
+		Gas:    gas,
+		Step:   s.steps,
+		Stack:  make([]uint256.Int, len(data)),
+		Memory: bytes.Clone(scope.MemoryData()),
+		Instr:  s.info.InstructionAt(int(pc)),
+	}
+	for i := range data {
+		f.Stack[i] = data[len(data)-1-i]
+	}
+	f.StackNames = StackNames(s.lastInstr, f.Instr)
+	s.lastInstr = f.Instr
+	if (f.Op == vm.SLOAD || f.Op == vm.SSTORE) && len(f.Stack) > 0 {
+		s.slots[common.Hash(f.Stack[0].Bytes32())] = struct{}{}
+	}
+	s.steps++
+
+	s.pause <- f
+	<-s.resume
+}
+
+// wait blocks until execution pauses or ends.
+func (s *Session) wait() {
+	select {
+	case f := <-s.pause:
+		s.cur = f
+	case o := <-s.done:
+		s.cur = nil
+		s.result, s.err = o.res, o.err
+	}
+}
+
+// Current returns the current execution state.
+// It returns nil if execution has ended.
+func (s *Session) Current() *Frame {
+	return s.cur
+}
+
+// Result returns the outcome of execution. It returns nil while execution
+// has not ended yet. The error is non-nil if the execution config was invalid.
+func (s *Session) Result() (*evmrun.Result, error) {
+	return s.result, s.err
+}
+
+// Info returns the debug information of the program.
+func (s *Session) Info() *asm.DebugInfo {
+	return s.info
+}
+
+// Step executes one instruction.
+func (s *Session) Step() {
+	if s.cur == nil {
+		return
+	}
+	s.resume <- struct{}{}
+	s.wait()
+}
+
+// Next executes instructions until the source line changes,
+// or a breakpoint is reached.
+func (s *Session) Next() *Breakpoint {
+	if s.cur == nil {
+		return nil
+	}
+	start := s.cur.Instr
+	for {
+		s.Step()
+		if s.cur == nil {
+			return nil
+		}
+		if bp := s.breakpointAt(s.cur.PC); bp != nil {
+			return bp
+		}
+		if !sameLine(start, s.cur.Instr) {
+			return nil
+		}
+	}
+}
+
+// Continue executes instructions until a breakpoint is reached, or execution ends.
+func (s *Session) Continue() *Breakpoint {
+	for s.cur != nil {
+		s.Step()
+		if s.cur != nil {
+			if bp := s.breakpointAt(s.cur.PC); bp != nil {
+				return bp
+			}
+		}
+	}
+	return nil
+}
+
+// Close aborts debugging. The remaining instructions of the program are executed in
+// the background.
+func (s *Session) Close() {
+	s.detached.Store(true)
+	if s.cur != nil {
+		s.cur = nil
+		s.resume <- struct{}{}
+	}
+}
+
+// StackNames returns the names of stack items before the execution of cur. The stack
+// comment of the previously executed instruction is used for this. Note that statements
+// can produce multiple instructions. The comment only applies after the last one.
+func StackNames(prev, cur *asm.DebugInstruction) []string {
+	if prev == nil || (cur != nil && prev.Pos == cur.Pos) {
+		return nil
+	}
+	return prev.StackItems()
+}
+
+func sameLine(a, b *asm.DebugInstruction) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Pos.File == b.Pos.File && a.Pos.Line == b.Pos.Line
+}
+
+// Slot is a storage slot.
+type Slot struct {
+	Key   common.Hash
+	Value common.Hash
+}
+
+// Storage returns the current values of storage slots which were accessed by the
+// program, or given in the initial storage.
+func (s *Session) Storage() []Slot {
+	slots := make([]Slot, 0, len(s.slots))
+	for key := range s.slots {
+		slots = append(slots, Slot{key, s.state.GetState(s.addr, key)})
+	}
+	slices.SortFunc(slots, func(a, b Slot) int {
+		return bytes.Compare(a.Key[:], b.Key[:])
+	})
+	return slots
+}
+
+// AddBreakpoint creates a breakpoint. The spec can be a label name, a line number
+// in the main file, or file:line.
+func (s *Session) AddBreakpoint(spec string) (*Breakpoint, error) {
+	pcs, err := s.resolve(spec)
+	if err != nil {
+		return nil, err
+	}
+	s.nextBP++
+	bp := &Breakpoint{ID: s.nextBP, Spec: spec, PCs: pcs}
+	s.breakpoints = append(s.breakpoints, bp)
+	return bp, nil
+}
+
+// RemoveBreakpoint deletes a breakpoint.
+func (s *Session) RemoveBreakpoint(id int) error {
+	i := slices.IndexFunc(s.breakpoints, func(bp *Breakpoint) bool { return bp.ID == id })
+	if i < 0 {
+		return fmt.Errorf("no breakpoint %d", id)
+	}
+	s.breakpoints = slices.Delete(s.breakpoints, i, i+1)
+	return nil
+}
+
+// Breakpoints returns all breakpoints.
+func (s *Session) Breakpoints() []*Breakpoint {
+	return s.breakpoints
+}
+
+func (s *Session) breakpointAt(pc uint64) *Breakpoint {
+	for _, bp := range s.breakpoints {
+		if slices.Contains(bp.PCs, int(pc)) {
+			return bp
+		}
+	}
+	return nil
+}
+
+// resolve finds the program counters of a breakpoint location.
+func (s *Session) resolve(spec string) ([]int, error) {
+	file, lineText := s.mainFile, spec
+	if i := strings.LastIndexByte(spec, ':'); i >= 0 {
+		file, lineText = spec[:i], spec[i+1:]
+	}
+	line, err := strconv.Atoi(lineText)
+	if err != nil {
+		return s.resolveLabel(spec)
+	}
+
+	// Stop at the first instruction of each sequence generated by the line.
+	var pcs []int
+	for i, di := range s.info.Instructions {
+		if di.Pos.File != file || di.Pos.Line != line {
+			continue
+		}
+		if i > 0 && sameLine(&s.info.Instructions[i-1], &di) {
+			continue
+		}
+		pcs = append(pcs, di.PC)
+	}
+	if len(pcs) == 0 {
+		return nil, fmt.Errorf("no code at %s:%d", file, line)
+	}
+	return pcs, nil
+}
+
+func (s *Session) resolveLabel(name string) ([]int, error) {
+	name = strings.TrimPrefix(name, "@")
+	var pcs []int
+	for _, l := range s.info.Labels {
+		if l.Name == name {
+			pcs = append(pcs, l.PC)
+		}
+	}
+	if len(pcs) == 0 {
+		return nil, fmt.Errorf("unknown label %q", name)
+	}
+	return pcs, nil
+}
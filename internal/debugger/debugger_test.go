@@ -0,0 +1,148 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+const testProgram = `
+#define %Double() {
+    dup1                ; [x, x]
+    add                 ; [2x]
+}
+
+    push 3              ; [x]
+    %Double()           ; [2x]
+    dup1                ; [2x, 2x]
+    push 1              ; [slot, 2x, 2x]
+    sstore              ; [2x]
+    jump @store         ; [2x]
+
+store:
+    push 0              ; [offset, 2x]
+    mstore              ; []
+    push 32             ; [size]
+    push 0              ; [offset, size]
+    return
+`
+
+func word(v int64) common.Hash {
+	return common.BigToHash(big.NewInt(v))
+}
+
+func startSession(t *testing.T) *Session {
+	t.Helper()
+	c := asm.New(nil)
+	code := c.CompileString(testProgram)
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	s := Start(code, c.DebugInfo(), "", evmrun.Config{})
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestStep(t *testing.T) {
+	s := startSession(t)
+	if f := s.Current(); f == nil || f.PC != 0 || f.Op != vm.PUSH1 {
+		t.Fatalf("wrong initial frame %+v", f)
+	}
+	s.Step()
+	f := s.Current()
+	if f.Op != vm.DUP1 || f.Instr.Pos.Line != 3 {
+		t.Fatalf("wrong frame after step: %v at %v", f.Op, f.Instr.Pos)
+	}
+	if len(f.Instr.Expansion) != 1 || f.Instr.Expansion[0].Macro != "Double" {
+		t.Errorf("wrong expansion %+v", f.Instr.Expansion)
+	}
+	if !slices.Equal(f.StackNames, []string{"x"}) {
+		t.Errorf("wrong stack names %q", f.StackNames)
+	}
+	if len(f.Stack) != 1 || f.Stack[0].Uint64() != 3 {
+		t.Errorf("wrong stack %v", f.Stack)
+	}
+}
+
+func TestNext(t *testing.T) {
+	s := startSession(t)
+	var lines []int
+	for s.Current() != nil {
+		lines = append(lines, s.Current().Instr.Pos.Line)
+		s.Next()
+	}
+	// The jump on line 12 is two instructions.
+	want := []int{7, 3, 4, 9, 10, 11, 12, 14, 15, 16, 17, 18, 19}
+	if !slices.Equal(lines, want) {
+		t.Errorf("wrong lines %v\nwant %v", lines, want)
+	}
+	res, err := s.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Err != nil || common.BytesToHash(res.ReturnData) != word(6) {
+		t.Errorf("wrong result %x (err %v)", res.ReturnData, res.Err)
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	s := startSession(t)
+	bp1, err := s.AddBreakpoint("store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp2, err := s.AddBreakpoint("18")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddBreakpoint("nolabel"); err == nil {
+		t.Error("expected error for unknown label")
+	}
+	if _, err := s.AddBreakpoint("1"); err == nil {
+		t.Error("expected error for line without code")
+	}
+
+	if bp := s.Continue(); bp != bp1 {
+		t.Fatalf("wrong breakpoint %v", bp)
+	}
+	if f := s.Current(); f.Op != vm.JUMPDEST {
+		t.Errorf("wrong op %v at breakpoint", f.Op)
+	}
+	storage := s.Storage()
+	if len(storage) != 1 || storage[0].Key != word(1) || storage[0].Value != word(6) {
+		t.Errorf("wrong storage %v", storage)
+	}
+	if bp := s.Continue(); bp != bp2 {
+		t.Fatalf("wrong breakpoint %v", bp)
+	}
+	if err := s.RemoveBreakpoint(bp1.ID); err != nil {
+		t.Fatal(err)
+	}
+	if bp := s.Continue(); bp != nil {
+		t.Fatalf("unexpected breakpoint %v", bp)
+	}
+	if s.Current() != nil {
+		t.Fatal("execution did not end")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package evmrun
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/fjl/geas/internal/evm"
+)
+
+// ChainConfig creates a go-ethereum chain configuration where all forks up to and
+// including the given one are active at genesis.
+//
+// Instruction sets which do not correspond to an Ethereum mainnet fork, such as "tron",
+// are mapped to the closest ancestor known to go-ethereum.
+func ChainConfig(fork string) (*params.ChainConfig, error) {
+	is := evm.FindInstructionSet(fork)
+	if is == nil {
+		return nil, fmt.Errorf("unknown fork %q", fork)
+	}
+	active := make(map[string]bool)
+	active[is.Name()] = true
+	for _, name := range is.Parents() {
+		active[name] = true
+	}
+
+	var (
+		zero     = func() *big.Int { return new(big.Int) }
+		zeroTime = func() *uint64 { return new(uint64) }
+		cfg      = &params.ChainConfig{ChainID: big.NewInt(1)}
+	)
+	if active["homestead"] {
+		cfg.HomesteadBlock = zero()
+	}
+	if active["tangerinewhistle"] {
+		cfg.EIP150Block = zero()
+	}
+	if active["spuriousdragon"] {
+		cfg.EIP155Block = zero()
+		cfg.EIP158Block = zero()
+	}
+	if active["byzantium"] {
+		cfg.ByzantiumBlock = zero()
+	}
+	// Note geas defines constantinople as a successor of petersburg, because petersburg
+	// has the instruction set of byzantium.
+	if active["constantinople"] {
+		cfg.ConstantinopleBlock = zero()
+		cfg.PetersburgBlock = zero()
+	}
+	if active["istanbul"] {
+		cfg.IstanbulBlock = zero()
+		cfg.MuirGlacierBlock = zero()
+	}
+	if active["berlin"] {
+		cfg.BerlinBlock = zero()
+	}
+	if active["london"] {
+		cfg.LondonBlock = zero()
+		cfg.ArrowGlacierBlock = zero()
+		cfg.GrayGlacierBlock = zero()
+	}
+	if active["paris"] {
+		cfg.TerminalTotalDifficulty = zero()
+		cfg.MergeNetsplitBlock = zero()
+	}
+	if active["shanghai"] {
+		cfg.ShanghaiTime = zeroTime()
+	}
+	if active["cancun"] {
+		cfg.CancunTime = zeroTime()
+		cfg.BlobScheduleConfig = params.DefaultBlobSchedule
+	}
+	if active["prague"] {
+		cfg.PragueTime = zeroTime()
+	}
+	if active["osaka"] {
+		cfg.OsakaTime = zeroTime()
+	}
+	if active["amsterdam"] {
+		cfg.AmsterdamTime = zeroTime()
+	}
+	return cfg, nil
+}
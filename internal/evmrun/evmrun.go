@@ -0,0 +1,207 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package evmrun executes EVM bytecode in an in-memory state.
+package evmrun
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/fjl/geas/internal/evm"
+	"github.com/holiman/uint256"
+)
+
+// Defaults for Config fields.
+var (
+	DefaultAddress  = common.HexToAddress("0x00000000000000000000000000000000c0dec0de")
+	DefaultCaller   = common.HexToAddress("0x000000000000000000000000000000000000ca11")
+	DefaultGasLimit = uint64(30_000_000)
+)
+
+// Config contains the parameters of an execution.
+type Config struct {
+	Fork     string         // instruction set name, defaults to evm.LatestFork
+	Input    []byte         // calldata
+	Value    *big.Int       // callvalue
+	Caller   common.Address // defaults to DefaultCaller
+	Address  common.Address // address of the code, defaults to DefaultAddress
+	GasLimit uint64         // defaults to DefaultGasLimit
+
+	// Storage is the initial storage of the executing account. Note this is applied on
+	// top of State, if given.
+	Storage map[common.Hash]common.Hash
+
+	// State is the pre-state. If nil, execution starts with an empty state.
+	State *state.StateDB
+
+	// Tracer receives EVM events.
+	Tracer *tracing.Hooks
+}
+
+// Result is the outcome of an execution.
+type Result struct {
+	ReturnData []byte
+	Err        error  // execution error, vm.ErrExecutionReverted for REVERT
+	GasUsed    uint64 // gas used by execution, not including intrinsic gas
+
+	// RevertReason is the decoded revert reason, for reverts with Error(string) or
+	// Panic(uint256) data.
+	RevertReason string
+
+	Logs    []*types.Log    // logs emitted by the execution
+	Storage []StorageChange // storage modifications, sorted by address and slot
+
+	// These describe the last instruction executed by the toplevel call frame.
+	LastPC uint64
+	LastOp vm.OpCode
+
+	// State is the post-state.
+	State *state.StateDB
+}
+
+// StorageChange is a modification of a storage slot.
+type StorageChange struct {
+	Address common.Address
+	Slot    common.Hash
+	Before  common.Hash
+	After   common.Hash
+}
+
+// Reverted reports whether execution ended with REVERT.
+func (r *Result) Reverted() bool {
+	return errors.Is(r.Err, vm.ErrExecutionReverted)
+}
+
+type storageKey struct {
+	addr common.Address
+	slot common.Hash
+}
+
+// Run executes code.
+//
+// This returns an error only when the configuration is invalid. Errors during execution
+// are reported in the result.
+func Run(code []byte, cfg Config) (*Result, error) {
+	if cfg.Fork == "" {
+		cfg.Fork = evm.LatestFork
+	}
+	chaincfg, err := ChainConfig(cfg.Fork)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Value == nil {
+		cfg.Value = new(big.Int)
+	}
+	if cfg.Value.Sign() < 0 || cfg.Value.BitLen() > 256 {
+		return nil, errors.New("invalid callvalue")
+	}
+	if cfg.Caller == (common.Address{}) {
+		cfg.Caller = DefaultCaller
+	}
+	if cfg.Address == (common.Address{}) {
+		cfg.Address = DefaultAddress
+	}
+	if cfg.GasLimit == 0 {
+		cfg.GasLimit = DefaultGasLimit
+	}
+	statedb := cfg.State
+	if statedb == nil {
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	}
+
+	// Set up the pre-state.
+	if !statedb.Exist(cfg.Address) {
+		statedb.CreateAccount(cfg.Address)
+	}
+	statedb.SetCode(cfg.Address, code, tracing.CodeChangeUnspecified)
+	for slot, value := range cfg.Storage {
+		statedb.SetState(cfg.Address, slot, value)
+	}
+	statedb.AddBalance(cfg.Caller, uint256.MustFromBig(cfg.Value), tracing.BalanceChangeUnspecified)
+
+	// Track storage writes and the last instruction using the tracer.
+	var (
+		res     = &Result{State: statedb}
+		before  = make(map[storageKey]common.Hash)
+		touched []storageKey
+		hooks   = new(tracing.Hooks)
+	)
+	if cfg.Tracer != nil {
+		*hooks = *cfg.Tracer
+	}
+	hooks.OnOpcode = func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+		if depth == 1 {
+			res.LastPC, res.LastOp = pc, vm.OpCode(op)
+		}
+		if vm.OpCode(op) == vm.SSTORE {
+			if stack := scope.StackData(); len(stack) > 0 {
+				key := storageKey{scope.Address(), stack[len(stack)-1].Bytes32()}
+				if _, ok := before[key]; !ok {
+					before[key] = statedb.GetState(key.addr, key.slot)
+					touched = append(touched, key)
+				}
+			}
+		}
+		if cfg.Tracer != nil && cfg.Tracer.OnOpcode != nil {
+			cfg.Tracer.OnOpcode(pc, op, gas, cost, scope, rData, depth, err)
+		}
+	}
+
+	// The state may contain logs of earlier executions.
+	nlogs := len(statedb.Logs())
+
+	rcfg := &runtime.Config{
+		ChainConfig: chaincfg,
+		Origin:      cfg.Caller,
+		GasLimit:    cfg.GasLimit,
+		Value:       cfg.Value,
+		State:       statedb,
+		EVMConfig:   vm.Config{Tracer: hooks},
+	}
+	ret, leftOverGas, err := runtime.Call(cfg.Address, cfg.Input, rcfg)
+	res.ReturnData = ret
+	res.Err = err
+	res.GasUsed = cfg.GasLimit - leftOverGas
+	if res.Reverted() {
+		res.RevertReason, _ = abi.UnpackRevert(ret)
+	}
+	res.Logs = statedb.Logs()[nlogs:]
+
+	// Compute the storage diff.
+	for _, key := range touched {
+		after := statedb.GetState(key.addr, key.slot)
+		if after != before[key] {
+			res.Storage = append(res.Storage, StorageChange{key.addr, key.slot, before[key], after})
+		}
+	}
+	slices.SortFunc(res.Storage, func(a, b StorageChange) int {
+		if c := a.Address.Cmp(b.Address); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.Slot[:], b.Slot[:])
+	})
+	return res, nil
+}
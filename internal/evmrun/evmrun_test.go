@@ -0,0 +1,144 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package evmrun
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+)
+
+func compile(t *testing.T, code string) []byte {
+	t.Helper()
+	c := asm.New(nil)
+	bin := c.CompileString(code)
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	return bin
+}
+
+func TestRunStorageAndLogs(t *testing.T) {
+	code := compile(t, `
+		push 2
+		push 1
+		sstore              ; slot 1 = 2
+		push 0
+		push 2
+		sstore              ; slot 2 = 0 (was 5)
+		push 3
+		push 3
+		sstore              ; slot 3 = 3 (unchanged)
+		callvalue
+		push 0
+		mstore
+		push 0xff
+		push 32
+		push 0
+		log1
+		push 32
+		push 0
+		return
+	`)
+	res, err := Run(code, Config{
+		Value: big.NewInt(7),
+		Storage: map[common.Hash]common.Hash{
+			common.HexToHash("0x02"): common.HexToHash("0x05"),
+			common.HexToHash("0x03"): common.HexToHash("0x03"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Err != nil {
+		t.Fatal("execution error:", res.Err)
+	}
+	if !bytes.Equal(res.ReturnData, common.LeftPadBytes([]byte{7}, 32)) {
+		t.Errorf("wrong return data %x", res.ReturnData)
+	}
+	want := []StorageChange{
+		{DefaultAddress, common.HexToHash("0x01"), common.Hash{}, common.HexToHash("0x02")},
+		{DefaultAddress, common.HexToHash("0x02"), common.HexToHash("0x05"), common.Hash{}},
+	}
+	if len(res.Storage) != len(want) {
+		t.Fatalf("wrong storage diff %v", res.Storage)
+	}
+	for i := range want {
+		if res.Storage[i] != want[i] {
+			t.Errorf("wrong storage change %d: %v", i, res.Storage[i])
+		}
+	}
+	if len(res.Logs) != 1 || res.Logs[0].Topics[0] != common.HexToHash("0xff") {
+		t.Errorf("wrong logs %v", res.Logs)
+	}
+	if res.LastOp != vm.RETURN {
+		t.Errorf("wrong last op %v", res.LastOp)
+	}
+}
+
+func TestRunRevert(t *testing.T) {
+	// This reverts with Error("no").
+	code := compile(t, `
+		push 0x08c379a0 << 224
+		push 0
+		mstore
+		push 32
+		push 4
+		mstore
+		push 2
+		push 36
+		mstore
+		push "no" << (30*8)
+		push 68
+		mstore
+		push 100
+		push 0
+		revert
+	`)
+	res, err := Run(code, Config{Fork: "cancun"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Reverted() {
+		t.Fatalf("expected revert, got err %v", res.Err)
+	}
+	if res.RevertReason != "no" {
+		t.Errorf("wrong revert reason %q", res.RevertReason)
+	}
+}
+
+func TestRunFork(t *testing.T) {
+	// PUSH0 is invalid before shanghai.
+	code := []byte{byte(vm.PUSH0), byte(vm.STOP)}
+	res, err := Run(code, Config{Fork: "paris"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Err == nil {
+		t.Error("expected error for PUSH0 on paris")
+	}
+	if _, err := Run(code, Config{Fork: "tron"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := Run(code, Config{Fork: "unknown"}); err == nil {
+		t.Error("expected error for unknown fork")
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package fuzz runs contracts with random inputs to find execution failures.
+//
+// The fuzzer generates calldata, callvalue and caller for calls into the contract.
+// Inputs which reach new code paths are kept in the corpus and mutated further. A call
+// fails when it ends in an invalid jump, stack underflow or overflow, or an invalid
+// opcode. When an invariant check contract is configured, it also fails when the
+// invariant does not hold after a successful call.
+package fuzz
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/testfile"
+)
+
+// Config contains the parameters of a fuzzer.
+type Config struct {
+	Code []byte
+	Info *asm.DebugInfo
+
+	// Call is the template of calls into the contract. Its input, value and caller
+	// are used as the initial corpus entry.
+	Call evmrun.Config
+
+	// Invariant is the code of the invariant check contract. It is optional.
+	// See testfile.CheckInvariant.
+	Invariant []byte
+
+	// Seed initializes the random number generator.
+	Seed uint64
+}
+
+// Kind is the type of a failure.
+type Kind int
+
+const (
+	InvalidJump Kind = iota
+	StackUnderflow
+	StackOverflow
+	InvalidOpcode
+	InvariantViolation
+)
+
+func (k Kind) String() string {
+	switch k {
+	case InvalidJump:
+		return "invalid jump"
+	case StackUnderflow:
+		return "stack underflow"
+	case StackOverflow:
+		return "stack overflow"
+	case InvalidOpcode:
+		return "invalid opcode"
+	case InvariantViolation:
+		return "invariant violation"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Crash is a failing input.
+type Crash struct {
+	Kind  Kind
+	Err   error  // execution error or invariant failure
+	PC    uint64 // last instruction executed by the contract
+	Input Input
+
+	// Instr is the instruction at PC. It is nil if execution ended outside of the program.
+	Instr *asm.DebugInstruction
+}
+
+// Input is the input of a call.
+type Input struct {
+	Data   []byte
+	Value  *big.Int
+	Caller common.Address
+}
+
+type crashKey struct {
+	kind Kind
+	pc   uint64
+}
+
+// Fuzzer generates inputs.
+type Fuzzer struct {
+	cfg      Config
+	rng      *rand.Rand
+	corpus   []Input
+	features map[uint64]bool // covered instructions and branches
+	dict     [][]byte        // constants from the code
+	callers  []common.Address
+	seen     map[crashKey]bool
+	crashes  []*Crash
+
+	// Execs counts the calls made by the fuzzer.
+	Execs int
+}
+
+// New creates a fuzzer.
+func New(cfg Config) *Fuzzer {
+	f := &Fuzzer{
+		cfg:      cfg,
+		rng:      rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15)),
+		features: make(map[uint64]bool),
+		seen:     make(map[crashKey]bool),
+		callers:  []common.Address{evmrun.DefaultCaller},
+	}
+	f.dict, f.callers = codeConstants(cfg.Code, cfg.Info, f.callers)
+	seed := Input{Data: cfg.Call.Input, Value: cfg.Call.Value, Caller: cfg.Call.Caller}
+	if seed.Caller == (common.Address{}) {
+		seed.Caller = evmrun.DefaultCaller
+	}
+	f.corpus = []Input{seed}
+	return f
+}
+
+// Coverage returns the number of instructions and branch directions reached so far.
+func (f *Fuzzer) Coverage() int {
+	return len(f.features)
+}
+
+// CorpusSize returns the number of inputs in the corpus.
+func (f *Fuzzer) CorpusSize() int {
+	return len(f.corpus)
+}
+
+// Crashes returns the failures found so far, in order of discovery.
+func (f *Fuzzer) Crashes() []*Crash {
+	return f.crashes
+}
+
+// Step runs the contract with a new input. When the input causes a failure that has
+// not been seen before, the failure is minimized and returned.
+func (f *Fuzzer) Step() (*Crash, error) {
+	in := f.generate()
+	crash, features, err := f.exec(in)
+	if err != nil {
+		return nil, err
+	}
+	if f.addFeatures(features) {
+		f.corpus = append(f.corpus, in)
+	}
+	if crash == nil {
+		return nil, nil
+	}
+	key := crashKey{crash.Kind, crash.PC}
+	if f.seen[key] {
+		return nil, nil
+	}
+	f.seen[key] = true
+	crash, err = f.minimize(crash)
+	if err != nil {
+		return nil, err
+	}
+	f.crashes = append(f.crashes, crash)
+	return crash, nil
+}
+
+func (f *Fuzzer) addFeatures(features []uint64) bool {
+	var added bool
+	for _, ft := range features {
+		if !f.features[ft] {
+			f.features[ft] = true
+			added = true
+		}
+	}
+	return added
+}
+
+// exec runs the contract with the given input. It returns the failure caused by the
+// input, and the features reached by execution.
+func (f *Fuzzer) exec(in Input) (*Crash, []uint64, error) {
+	f.Execs++
+	var features []uint64
+	cfg := f.cfg.Call
+	cfg.Input, cfg.Value, cfg.Caller = in.Data, in.Value, in.Caller
+	cfg.State = nil
+	cfg.Tracer = &tracing.Hooks{
+		OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+			if depth != 1 {
+				return
+			}
+			ft := pc << 2
+			if vm.OpCode(op) == vm.JUMPI {
+				if stack := scope.StackData(); len(stack) >= 2 {
+					if stack[len(stack)-2].IsZero() {
+						ft |= 1
+					} else {
+						ft |= 2
+					}
+				}
+			}
+			features = append(features, ft)
+		},
+	}
+	res, err := evmrun.Run(f.cfg.Code, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	crash := &Crash{Err: res.Err, PC: res.LastPC, Input: in}
+	var (
+		underflow *vm.ErrStackUnderflow
+		overflow  *vm.ErrStackOverflow
+		invalidOp *vm.ErrInvalidOpCode
+	)
+	switch {
+	case res.Err == nil:
+		if f.cfg.Invariant == nil {
+			return nil, features, nil
+		}
+		err := testfile.CheckInvariant(f.cfg.Invariant, cfg.Fork, res.State)
+		if err == nil {
+			return nil, features, nil
+		}
+		crash.Kind, crash.Err = InvariantViolation, err
+	case errors.Is(res.Err, vm.ErrInvalidJump):
+		crash.Kind = InvalidJump
+	case errors.As(res.Err, &underflow):
+		crash.Kind = StackUnderflow
+	case errors.As(res.Err, &overflow):
+		crash.Kind = StackOverflow
+	case errors.As(res.Err, &invalidOp):
+		crash.Kind = InvalidOpcode
+	default:
+		return nil, features, nil
+	}
+	crash.Instr = f.cfg.Info.InstructionAt(int(crash.PC))
+	return crash, features, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzz
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+func compile(t *testing.T, code string) ([]byte, *asm.DebugInfo) {
+	t.Helper()
+	c := asm.New(nil)
+	bin := c.CompileString(code)
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	return bin, c.DebugInfo()
+}
+
+func run(t *testing.T, f *Fuzzer, steps int) {
+	t.Helper()
+	for range steps {
+		if _, err := f.Step(); err != nil {
+			t.Fatal(err)
+		}
+		if len(f.Crashes()) > 0 {
+			return
+		}
+	}
+}
+
+func TestInvalidJump(t *testing.T) {
+	code, info := compile(t, `
+	push 0
+	calldataload
+	push 224
+	shr                     ; [sel]
+	dup1                    ; [sel, sel]
+	push 0x11223344         ; [a, sel, sel]
+	eq                      ; [sel==a, sel]
+	jumpi @a                ; [sel]
+	push 0x55667788         ; [b, sel]
+	eq                      ; [sel==b]
+	jumpi @b                ; []
+	push 0
+	push 0
+	revert
+
+a:
+	stop
+
+b:
+	push 4
+	calldataload            ; [dest]
+	jump                    ; []
+`)
+	f := New(Config{Code: code, Info: info, Seed: 1})
+	run(t, f, 10000)
+
+	crashes := f.Crashes()
+	if len(crashes) != 1 {
+		t.Fatalf("found %d crashes, want 1", len(crashes))
+	}
+	c := crashes[0]
+	if c.Kind != InvalidJump {
+		t.Errorf("wrong kind %v", c.Kind)
+	}
+	if c.Instr == nil || c.Instr.Op != "JUMP" || c.Instr.Pos.Line != 23 {
+		t.Errorf("wrong instruction %+v", c.Instr)
+	}
+	if want := []byte{0x55, 0x66, 0x77, 0x88}; !bytes.Equal(c.Input.Data, want) {
+		t.Errorf("input not minimized: %x", c.Input.Data)
+	}
+	if c.Input.Value != nil || c.Input.Caller != evmrun.DefaultCaller {
+		t.Errorf("value/caller not minimized: %v %v", c.Input.Value, c.Input.Caller)
+	}
+}
+
+func TestInvariant(t *testing.T) {
+	code, info := compile(t, `
+	push 0
+	calldataload            ; [x]
+	push 0                  ; [slot, x]
+	sstore                  ; []
+`)
+	invariant, _ := compile(t, `
+	push 0
+	sload                   ; [x]
+	push 100                ; [100, x]
+	gt                      ; [100>x]
+	jumpi @ok               ; []
+	push 0
+	push 0
+	revert
+ok:
+	stop
+`)
+	f := New(Config{Code: code, Info: info, Invariant: invariant, Seed: 1})
+	run(t, f, 10000)
+
+	crashes := f.Crashes()
+	if len(crashes) != 1 {
+		t.Fatalf("found %d crashes, want 1", len(crashes))
+	}
+	if c := crashes[0]; c.Kind != InvariantViolation {
+		t.Errorf("wrong kind %v (%v)", c.Kind, c.Err)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzz
+
+import (
+	"slices"
+
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+// minimizeLimit is the maximum number of executions spent minimizing a crash.
+const minimizeLimit = 2000
+
+// minimize reduces the input of a crash while preserving the failure.
+// The callvalue and caller are reset if possible, then the calldata is shortened and
+// bytes are cleared.
+func (f *Fuzzer) minimize(crash *Crash) (*Crash, error) {
+	var (
+		best   = crash
+		budget = minimizeLimit
+	)
+	try := func(in Input) (bool, error) {
+		if budget == 0 {
+			return false, nil
+		}
+		budget--
+		c, _, err := f.exec(in)
+		if err != nil || c == nil || c.Kind != crash.Kind || c.PC != crash.PC {
+			return false, err
+		}
+		best = c
+		return true, nil
+	}
+
+	// Simplify value and caller.
+	if best.Input.Value != nil {
+		in := best.Input
+		in.Value = nil
+		if _, err := try(in); err != nil {
+			return nil, err
+		}
+	}
+	if best.Input.Caller != evmrun.DefaultCaller {
+		in := best.Input
+		in.Caller = evmrun.DefaultCaller
+		if _, err := try(in); err != nil {
+			return nil, err
+		}
+	}
+
+	// Remove chunks of calldata, starting with large chunks.
+	for size := len(best.Input.Data) / 2; size > 0; size /= 2 {
+		for pos := 0; pos+size <= len(best.Input.Data); {
+			in := best.Input
+			in.Data = slices.Delete(slices.Clone(in.Data), pos, pos+size)
+			ok, err := try(in)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				pos += size
+			}
+		}
+	}
+
+	// Clear bytes.
+	for i := range best.Input.Data {
+		if best.Input.Data[i] == 0 {
+			continue
+		}
+		in := best.Input
+		in.Data = slices.Clone(in.Data)
+		in.Data[i] = 0
+		if _, err := try(in); err != nil {
+			return nil, err
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzz
+
+import (
+	"bytes"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fjl/geas/asm"
+)
+
+// maxInputSize is the size limit of generated calldata.
+const maxInputSize = 1024
+
+// interestingWords are values which often trigger edge cases.
+var interestingWords = []*big.Int{
+	big.NewInt(0),
+	big.NewInt(1),
+	big.NewInt(2),
+	big.NewInt(31),
+	big.NewInt(32),
+	big.NewInt(0xff),
+	big.NewInt(0x10000),
+	new(big.Int).Lsh(big.NewInt(1), 64),
+	new(big.Int).Lsh(big.NewInt(1), 255),
+	new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1)),
+	new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+}
+
+// interestingValues are the callvalues tried by the fuzzer.
+var interestingValues = []*big.Int{
+	big.NewInt(0),
+	big.NewInt(1),
+	big.NewInt(1e18),
+}
+
+// codeConstants collects the PUSH arguments of the program. Values which look like
+// addresses are also returned as callers.
+func codeConstants(code []byte, info *asm.DebugInfo, callers []common.Address) ([][]byte, []common.Address) {
+	var dict [][]byte
+	for _, di := range info.Instructions {
+		if !strings.HasPrefix(di.Op, "PUSH") || di.Size < 2 || di.PC+di.Size > len(code) {
+			continue
+		}
+		arg := code[di.PC+1 : di.PC+di.Size]
+		if slices.ContainsFunc(dict, func(v []byte) bool { return bytes.Equal(v, arg) }) {
+			continue
+		}
+		dict = append(dict, arg)
+		if len(arg) == 20 {
+			callers = append(callers, common.BytesToAddress(arg))
+		}
+	}
+	return dict, callers
+}
+
+// generate creates the next input. Most inputs are mutations of corpus entries.
+func (f *Fuzzer) generate() Input {
+	if f.rng.IntN(16) == 0 {
+		return f.randomInput()
+	}
+	parent := f.corpus[f.rng.IntN(len(f.corpus))]
+	in := Input{Data: slices.Clone(parent.Data), Value: parent.Value, Caller: parent.Caller}
+	for n := 1 + f.rng.IntN(4); n > 0; n-- {
+		f.mutate(&in)
+	}
+	return in
+}
+
+func (f *Fuzzer) randomInput() Input {
+	in := Input{Caller: f.callers[f.rng.IntN(len(f.callers))]}
+	if len(f.dict) > 0 && f.rng.IntN(2) == 0 {
+		in.Data = append(in.Data, f.dictEntry()...)
+	}
+	for n := f.rng.IntN(5); n > 0; n-- {
+		in.Data = append(in.Data, f.word()...)
+	}
+	if f.rng.IntN(4) == 0 {
+		in.Value = f.value()
+	}
+	return in
+}
+
+// mutate applies a random change to the input.
+func (f *Fuzzer) mutate(in *Input) {
+	d := in.Data
+	switch f.rng.IntN(10) {
+	case 0: // flip bit
+		if len(d) > 0 {
+			d[f.rng.IntN(len(d))] ^= 1 << f.rng.IntN(8)
+		}
+	case 1: // set byte
+		if len(d) > 0 {
+			d[f.rng.IntN(len(d))] = []byte{0, 1, 0x7f, 0x80, 0xff}[f.rng.IntN(5)]
+		}
+	case 2: // randomize byte
+		if len(d) > 0 {
+			d[f.rng.IntN(len(d))] = byte(f.rng.Uint32())
+		}
+	case 3: // overwrite word
+		if len(d) > 0 {
+			pos := f.rng.IntN(len(d))
+			if f.rng.IntN(2) == 0 {
+				pos -= pos % 32
+			}
+			copy(d[pos:], f.word())
+		}
+	case 4: // insert constant
+		if len(f.dict) > 0 {
+			pos := 0
+			if len(d) > 0 && f.rng.IntN(2) == 0 {
+				pos = f.rng.IntN(len(d))
+			}
+			d = slices.Insert(d, pos, f.dictEntry()...)
+		}
+	case 5: // append word
+		d = append(d, f.word()...)
+	case 6: // delete range
+		if len(d) > 0 {
+			start := f.rng.IntN(len(d))
+			end := start + 1 + f.rng.IntN(min(32, len(d)-start))
+			d = slices.Delete(d, start, end)
+		}
+	case 7: // truncate
+		if len(d) > 0 {
+			d = d[:f.rng.IntN(len(d))]
+		}
+	case 8:
+		in.Value = f.value()
+	case 9:
+		in.Caller = f.callers[f.rng.IntN(len(f.callers))]
+	}
+	if len(d) > maxInputSize {
+		d = d[:maxInputSize]
+	}
+	in.Data = d
+}
+
+// word returns a 32-byte value.
+func (f *Fuzzer) word() []byte {
+	switch f.rng.IntN(3) {
+	case 0:
+		return common.BigToHash(interestingWords[f.rng.IntN(len(interestingWords))]).Bytes()
+	case 1:
+		if len(f.dict) > 0 {
+			return common.LeftPadBytes(f.dictEntry(), 32)
+		}
+		fallthrough
+	default:
+		var w common.Hash
+		for i := range w {
+			w[i] = byte(f.rng.Uint32())
+		}
+		return w.Bytes()
+	}
+}
+
+func (f *Fuzzer) dictEntry() []byte {
+	return f.dict[f.rng.IntN(len(f.dict))]
+}
+
+func (f *Fuzzer) value() *big.Int {
+	if f.rng.IntN(4) == 0 {
+		return new(big.Int).SetUint64(f.rng.Uint64())
+	}
+	return interestingValues[f.rng.IntN(len(interestingValues))]
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package gasprof attributes the gas used by an execution to the source code
+// of the program.
+package gasprof
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fjl/geas/asm"
+)
+
+// Profile contains the gas usage of instructions.
+type Profile struct {
+	Info  *asm.DebugInfo
+	Total uint64 // total gas used
+
+	// PCs maps program counters to the usage of the instruction at that position.
+	PCs map[int]*Sample
+
+	// state during execution
+	gasLimit uint64
+	pending  *Sample
+	lastGas  uint64
+}
+
+// Sample is the gas usage of an instruction or source location.
+//
+// For source locations, which can contain multiple instructions, Count is the number of
+// times execution reached the location. For instruction macros and included files, it is
+// the number of times a call site was executed.
+type Sample struct {
+	Count uint64 // number of executions
+	Gas   uint64
+}
+
+// New creates an empty profile.
+func New(info *asm.DebugInfo) *Profile {
+	return &Profile{Info: info, PCs: make(map[int]*Sample)}
+}
+
+// Hooks returns the tracer hooks which record the profile. The hooks can only be
+// used for a single execution.
+//
+// Gas is attributed to instructions of the toplevel call frame. The gas used by an
+// instruction is computed as the difference of available gas before and after it,
+// so the usage of calls includes the gas used by the callee.
+func (p *Profile) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnTxStart: func(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+			p.gasLimit = tx.Gas()
+		},
+		OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+			if depth != 1 {
+				return
+			}
+			p.finishPending(gas)
+			s := p.PCs[int(pc)]
+			if s == nil {
+				s = new(Sample)
+				p.PCs[int(pc)] = s
+			}
+			s.Count++
+			p.pending, p.lastGas = s, gas
+		},
+		OnTxEnd: func(receipt *types.Receipt, err error) {
+			p.finishPending(p.gasLimit - receipt.GasUsed)
+			p.Total = receipt.GasUsed
+		},
+	}
+}
+
+func (p *Profile) finishPending(gas uint64) {
+	if p.pending != nil {
+		p.pending.Gas += p.lastGas - gas
+		p.pending = nil
+	}
+}
+
+// Location is a position in the source code.
+type Location struct {
+	File string
+	Line int
+}
+
+// LineSample is the gas usage of a source line.
+type LineSample struct {
+	Location
+	Flat Sample // usage of instructions on the line
+	Cum  Sample // usage including instruction macros and #include on the line
+}
+
+// Lines returns the gas usage of all source lines, ordered by file and line.
+func (p *Profile) Lines() []LineSample {
+	type lineUsage struct{ flat, cum usage }
+	lines := make(map[Location]*lineUsage)
+	get := func(loc Location) *lineUsage {
+		lu := lines[loc]
+		if lu == nil {
+			lu = new(lineUsage)
+			lines[loc] = lu
+		}
+		return lu
+	}
+	p.forEach(func(di *asm.DebugInstruction, s *Sample) {
+		loc := Location{di.Pos.File, di.Pos.Line}
+		lu := get(loc)
+		lu.flat.add(di.Expansion, s)
+		lu.cum.add(di.Expansion, s)
+		seen := map[Location]bool{loc: true}
+		for i, f := range di.Expansion {
+			loc := Location{f.Pos.File, f.Pos.Line}
+			if !seen[loc] {
+				seen[loc] = true
+				get(loc).cum.add(di.Expansion[i+1:], s)
+			}
+		}
+	})
+	result := make([]LineSample, 0, len(lines))
+	for loc, lu := range lines {
+		result = append(result, LineSample{loc, lu.flat.sample(), lu.cum.sample()})
+	}
+	slices.SortFunc(result, func(a, b LineSample) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	return result
+}
+
+// NamedSample is the gas usage of an instruction macro or included file.
+type NamedSample struct {
+	Name string
+	Sample
+}
+
+// Macros returns the cumulative gas usage of instruction macros,
+// ordered by gas usage.
+func (p *Profile) Macros() []NamedSample {
+	return p.frameUsage(func(f asm.ExpansionFrame) string { return f.Macro })
+}
+
+// Includes returns the cumulative gas usage of included files,
+// ordered by gas usage.
+func (p *Profile) Includes() []NamedSample {
+	return p.frameUsage(func(f asm.ExpansionFrame) string { return f.Include })
+}
+
+func (p *Profile) frameUsage(name func(asm.ExpansionFrame) string) []NamedSample {
+	frames := make(map[string]*usage)
+	p.forEach(func(di *asm.DebugInstruction, s *Sample) {
+		seen := make(map[string]bool)
+		for i, f := range di.Expansion {
+			n := name(f)
+			if n == "" || seen[n] {
+				continue
+			}
+			seen[n] = true
+			if frames[n] == nil {
+				frames[n] = new(usage)
+			}
+			frames[n].add(di.Expansion[i:], s)
+		}
+	})
+	result := make([]NamedSample, 0, len(frames))
+	for n, u := range frames {
+		result = append(result, NamedSample{n, u.sample()})
+	}
+	slices.SortFunc(result, func(a, b NamedSample) int {
+		return cmp.Or(cmp.Compare(b.Gas, a.Gas), cmp.Compare(a.Name, b.Name))
+	})
+	return result
+}
+
+// usage accumulates the gas usage of a source location. Since the code of a location
+// can be instantiated multiple times by macro expansion, execution counts are tracked
+// for each instance, identified by its expansion chain.
+type usage struct {
+	gas       uint64
+	instances map[string]uint64
+}
+
+func (u *usage) add(chain []asm.ExpansionFrame, s *Sample) {
+	if u.instances == nil {
+		u.instances = make(map[string]uint64)
+	}
+	var key strings.Builder
+	for _, f := range chain {
+		key.WriteString(f.Pos.String())
+		key.WriteByte(';')
+	}
+	k := key.String()
+	u.instances[k] = max(u.instances[k], s.Count)
+	u.gas += s.Gas
+}
+
+func (u *usage) sample() Sample {
+	s := Sample{Gas: u.gas}
+	for _, count := range u.instances {
+		s.Count += count
+	}
+	return s
+}
+
+// forEach calls fn for all executed instructions in PC order.
+func (p *Profile) forEach(fn func(*asm.DebugInstruction, *Sample)) {
+	for i := range p.Info.Instructions {
+		di := &p.Info.Instructions[i]
+		if s := p.PCs[di.PC]; s != nil {
+			fn(di, s)
+		}
+	}
+}
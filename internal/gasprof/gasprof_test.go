@@ -0,0 +1,123 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprof
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+var testFS = fstest.MapFS{
+	"main.eas": {Data: []byte(`#include "lib.eas"
+    push 7
+    %Double()
+    %Double()
+    pop
+    push 1
+    sload
+    pop
+    stop
+`)},
+	"lib.eas": {Data: []byte(`#define %Double() {
+    dup1
+    add
+}
+    push 1
+    pop
+`)},
+}
+
+func runProfile(t *testing.T) *Profile {
+	t.Helper()
+	c := asm.New(testFS)
+	code := c.CompileFile("main.eas")
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	prof := New(c.DebugInfo())
+	res, err := evmrun.Run(code, evmrun.Config{Tracer: prof.Hooks()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if prof.Total != res.GasUsed {
+		t.Fatalf("wrong total gas %d, want %d", prof.Total, res.GasUsed)
+	}
+	return prof
+}
+
+func TestProfile(t *testing.T) {
+	prof := runProfile(t)
+
+	want := []LineSample{
+		{Location{"lib.eas", 2}, Sample{2, 6}, Sample{2, 6}},
+		{Location{"lib.eas", 3}, Sample{2, 6}, Sample{2, 6}},
+		{Location{"lib.eas", 5}, Sample{1, 3}, Sample{1, 3}},
+		{Location{"lib.eas", 6}, Sample{1, 2}, Sample{1, 2}},
+		{Location{"main.eas", 1}, Sample{}, Sample{1, 5}},
+		{Location{"main.eas", 2}, Sample{1, 3}, Sample{1, 3}},
+		{Location{"main.eas", 3}, Sample{}, Sample{1, 6}},
+		{Location{"main.eas", 4}, Sample{}, Sample{1, 6}},
+		{Location{"main.eas", 5}, Sample{1, 2}, Sample{1, 2}},
+		{Location{"main.eas", 6}, Sample{1, 3}, Sample{1, 3}},
+		{Location{"main.eas", 7}, Sample{1, 2100}, Sample{1, 2100}},
+		{Location{"main.eas", 8}, Sample{1, 2}, Sample{1, 2}},
+		{Location{"main.eas", 9}, Sample{1, 0}, Sample{1, 0}},
+	}
+	if lines := prof.Lines(); !slices.Equal(lines, want) {
+		t.Errorf("wrong lines:\n have %v\n want %v", lines, want)
+	}
+
+	wantMacros := []NamedSample{{"Double", Sample{2, 12}}}
+	if macros := prof.Macros(); !slices.Equal(macros, wantMacros) {
+		t.Errorf("wrong macros %v", macros)
+	}
+	wantIncludes := []NamedSample{{"lib.eas", Sample{1, 5}}}
+	if includes := prof.Includes(); !slices.Equal(includes, wantIncludes) {
+		t.Errorf("wrong includes %v", includes)
+	}
+}
+
+func TestWritePprof(t *testing.T) {
+	prof := runProfile(t)
+	var buf bytes.Buffer
+	if err := prof.WritePprof(&buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"gas", "%Double", "main.eas", "lib.eas"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("profile does not contain string %q", s)
+		}
+	}
+}
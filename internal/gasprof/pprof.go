@@ -0,0 +1,195 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprof
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+
+	"github.com/fjl/geas/asm"
+)
+
+// WritePprof writes the profile in the pprof format, which can be viewed using
+// 'go tool pprof'. In the profile, instruction macros and included files appear as
+// functions, and the call stack of an instruction is its expansion chain.
+func (p *Profile) WritePprof(w io.Writer) error {
+	b := newPprofBuilder()
+	b.valueType("executions", "count")
+	b.valueType("gas", "gas")
+	p.forEach(func(di *asm.DebugInstruction, s *Sample) {
+		stack := make([]uint64, 0, len(di.Expansion)+1)
+		stack = append(stack, b.location(di, -1))
+		for i := range di.Expansion {
+			stack = append(stack, b.location(di, i))
+		}
+		b.sample(stack, s)
+	})
+	b.msg.bytesField(11, b.valueTypeMsg("gas", "gas"))
+	b.msg.varintField(12, 1)
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(b.finish()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// pprofBuilder creates a profile.proto message.
+type pprofBuilder struct {
+	msg       protoBuf
+	strings   map[string]int
+	stringTab []string
+	funcs     map[string]uint64
+	locs      map[pprofLoc]uint64
+}
+
+type pprofLoc struct {
+	fn   uint64
+	line int
+	pc   int
+}
+
+func newPprofBuilder() *pprofBuilder {
+	b := &pprofBuilder{
+		strings: make(map[string]int),
+		funcs:   make(map[string]uint64),
+		locs:    make(map[pprofLoc]uint64),
+	}
+	b.str("")
+	return b
+}
+
+func (b *pprofBuilder) str(s string) int {
+	if i, ok := b.strings[s]; ok {
+		return i
+	}
+	b.strings[s] = len(b.stringTab)
+	b.stringTab = append(b.stringTab, s)
+	return len(b.stringTab) - 1
+}
+
+func (b *pprofBuilder) valueTypeMsg(typ, unit string) []byte {
+	var m protoBuf
+	m.varintField(1, uint64(b.str(typ)))
+	m.varintField(2, uint64(b.str(unit)))
+	return m
+}
+
+func (b *pprofBuilder) valueType(typ, unit string) {
+	b.msg.bytesField(1, b.valueTypeMsg(typ, unit))
+}
+
+// function returns the ID of the function containing the instruction, or expansion
+// frame i of the instruction.
+func (b *pprofBuilder) function(di *asm.DebugInstruction, i int) uint64 {
+	var name, file string
+	switch {
+	case i+1 >= len(di.Expansion):
+		// toplevel
+		file = di.Pos.File
+		if len(di.Expansion) > 0 {
+			file = di.Expansion[len(di.Expansion)-1].Pos.File
+		}
+		name = file
+	case di.Expansion[i+1].Macro != "":
+		name = "%" + di.Expansion[i+1].Macro
+	default:
+		name = di.Expansion[i+1].Include
+	}
+	if i == -1 {
+		file = di.Pos.File
+	} else {
+		file = di.Expansion[i].Pos.File
+	}
+	key := name + "\x00" + file
+	if id, ok := b.funcs[key]; ok {
+		return id
+	}
+	id := uint64(len(b.funcs) + 1)
+	b.funcs[key] = id
+	var m protoBuf
+	m.varintField(1, id)
+	m.varintField(2, uint64(b.str(name)))
+	m.varintField(3, uint64(b.str(name)))
+	m.varintField(4, uint64(b.str(file)))
+	b.msg.bytesField(5, m)
+	return id
+}
+
+// location returns the location ID of the instruction (i = -1), or expansion
+// frame i of the instruction.
+func (b *pprofBuilder) location(di *asm.DebugInstruction, i int) uint64 {
+	key := pprofLoc{fn: b.function(di, i)}
+	if i == -1 {
+		key.line, key.pc = di.Pos.Line, di.PC
+	} else {
+		key.line, key.pc = di.Expansion[i].Pos.Line, -1
+	}
+	if id, ok := b.locs[key]; ok {
+		return id
+	}
+	id := uint64(len(b.locs) + 1)
+	b.locs[key] = id
+	var line, m protoBuf
+	line.varintField(1, key.fn)
+	line.varintField(2, uint64(key.line))
+	m.varintField(1, id)
+	if key.pc >= 0 {
+		m.varintField(3, uint64(key.pc))
+	}
+	m.bytesField(4, line)
+	b.msg.bytesField(4, m)
+	return id
+}
+
+func (b *pprofBuilder) sample(stack []uint64, s *Sample) {
+	var ids, values, m protoBuf
+	for _, id := range stack {
+		ids.varint(id)
+	}
+	values.varint(s.Count)
+	values.varint(s.Gas)
+	m.bytesField(1, ids)
+	m.bytesField(2, values)
+	b.msg.bytesField(2, m)
+}
+
+func (b *pprofBuilder) finish() []byte {
+	for _, s := range b.stringTab {
+		b.msg.bytesField(6, []byte(s))
+	}
+	return b.msg
+}
+
+// protoBuf is a protocol buffers encoder.
+type protoBuf []byte
+
+func (p *protoBuf) varint(v uint64) {
+	*p = binary.AppendUvarint(*p, v)
+}
+
+func (p *protoBuf) varintField(field int, v uint64) {
+	p.varint(uint64(field)<<3 | 0)
+	p.varint(v)
+}
+
+func (p *protoBuf) bytesField(field int, data []byte) {
+	p.varint(uint64(field)<<3 | 2)
+	p.varint(uint64(len(data)))
+	*p = append(*p, data...)
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package keyless creates contract deployment transactions using Nick's method.
+//
+// A keyless deployment transaction is a pre-EIP-155 transaction with a fixed signature
+// chosen by the deployer. The sender address is recovered from the signature, so nobody
+// knows the private key of the sender, and the transaction can only ever create one
+// contract at a well-known address. Anyone can submit the transaction after funding
+// the sender account.
+package keyless
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+// DefaultR is the default signature r value. This is used by the system contract
+// deployments of EIP-4788, EIP-2935 and others.
+var DefaultR = big.NewInt(0x539)
+
+var secp256k1halfN = new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+// Config contains the parameters of a deployment transaction.
+type Config struct {
+	Initcode []byte
+	GasPrice *big.Int
+	Gas      uint64
+	R, S     *big.Int
+
+	// MaxAttempts is the limit of the signature search.
+	MaxAttempts int
+}
+
+// Deployment is a keyless deployment transaction.
+type Deployment struct {
+	Tx       *types.Transaction
+	Sender   common.Address // recovered sender of Tx
+	Contract common.Address // address of the created contract
+	Attempts int            // number of signatures tried
+}
+
+// Cost returns the amount of wei the sender must hold to execute the transaction.
+func (d *Deployment) Cost() *big.Int {
+	return d.Tx.Cost()
+}
+
+// Build creates the transaction.
+//
+// Not every value is a valid r, because r must be the x-coordinate of a point on the
+// curve. When the configured r is invalid, it is incremented until a valid signature
+// is found. The s value must be in the lower half of the curve order.
+func Build(cfg Config) (*Deployment, error) {
+	if cfg.R == nil || cfg.R.Sign() <= 0 {
+		return nil, errors.New("r must be positive")
+	}
+	if cfg.S == nil || cfg.S.Sign() <= 0 || cfg.S.Cmp(secp256k1halfN) > 0 {
+		return nil, errors.New("s must be positive and at most secp256k1n/2")
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = 1000
+	}
+	tx := types.NewTx(&types.LegacyTx{
+		GasPrice: cfg.GasPrice,
+		Gas:      cfg.Gas,
+		Data:     cfg.Initcode,
+	})
+	signer := types.HomesteadSigner{}
+	r := new(big.Int).Set(cfg.R)
+	for attempt := 1; attempt <= cfg.MaxAttempts; attempt++ {
+		if r.Cmp(crypto.S256().Params().N) >= 0 {
+			break
+		}
+		sig := make([]byte, crypto.SignatureLength)
+		r.FillBytes(sig[:32])
+		cfg.S.FillBytes(sig[32:64])
+		signed, err := tx.WithSignature(signer, sig)
+		if err != nil {
+			return nil, err
+		}
+		if sender, err := types.Sender(signer, signed); err == nil {
+			d := &Deployment{
+				Tx:       signed,
+				Sender:   sender,
+				Contract: crypto.CreateAddress(sender, 0),
+				Attempts: attempt,
+			}
+			return d, nil
+		}
+		r.Add(r, common.Big1)
+	}
+	return nil, fmt.Errorf("no valid signature found in %d attempts", cfg.MaxAttempts)
+}
+
+// EstimateGas computes the gas used by a contract creation transaction with the given
+// initcode. This runs the initcode to find the execution cost and size of the created
+// contract.
+func EstimateGas(initcode []byte, fork string) (uint64, error) {
+	chaincfg, err := evmrun.ChainConfig(fork)
+	if err != nil {
+		return 0, err
+	}
+	res, err := evmrun.Run(initcode, evmrun.Config{Fork: fork})
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case res.Reverted() && res.RevertReason != "":
+		return 0, fmt.Errorf("constructor reverted: %s", res.RevertReason)
+	case res.Err != nil:
+		return 0, fmt.Errorf("constructor failed: %v", res.Err)
+	}
+
+	rules := chaincfg.Rules(new(big.Int), true, 0)
+	gas, err := core.IntrinsicGas(initcode, nil, nil, true, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+	if err != nil {
+		return 0, err
+	}
+	gas += res.GasUsed + uint64(len(res.ReturnData))*200
+	if rules.IsPrague {
+		floor, err := core.FloorDataGas(initcode)
+		if err != nil {
+			return 0, err
+		}
+		gas = max(gas, floor)
+	}
+	return gas, nil
+}
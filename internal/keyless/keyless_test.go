@@ -0,0 +1,98 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keyless
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// This is the initcode of the EIP-4788 deployment transaction.
+var initcode4788 = common.FromHex("0x60618060095f395ff33373fffffffffffffffffffffffffffffffffffffffe14604d57602036146024575f5ffd5b5f35801560495762001fff810690815414603c575f5ffd5b62001fff01545f5260205ff35b5f5ffd5b62001fff42064281555f359062001fff015500")
+
+// This checks that the EIP-4788 deployment transaction is reproduced.
+func TestBuild4788(t *testing.T) {
+	d, err := Build(Config{
+		Initcode: initcode4788,
+		GasPrice: big.NewInt(0xe8d4a51000),
+		Gas:      0x3d090,
+		R:        DefaultR,
+		S:        big.NewInt(0x1b9b6eb1f0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToAddress("0x0B799C86a49DEeb90402691F1041aa3AF2d3C875"); d.Sender != want {
+		t.Errorf("wrong sender %v, want %v", d.Sender, want)
+	}
+	if want := common.HexToAddress("0x000F3df6D732807Ef1319fB7B8bB8522d0Beac02"); d.Contract != want {
+		t.Errorf("wrong contract address %v, want %v", d.Contract, want)
+	}
+	if d.Attempts != 1 {
+		t.Errorf("wrong attempts %d", d.Attempts)
+	}
+}
+
+func TestBuildSearch(t *testing.T) {
+	// r = 5 is not the x-coordinate of a point on the curve.
+	d, err := Build(Config{
+		Initcode: initcode4788,
+		GasPrice: big.NewInt(1),
+		Gas:      100000,
+		R:        big.NewInt(5),
+		S:        big.NewInt(5),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, r, s := d.Tx.RawSignatureValues()
+	if d.Attempts < 2 || r.Int64() != int64(4+d.Attempts) || s.Int64() != 5 {
+		t.Errorf("wrong signature r=%v s=%v after %d attempts", r, s, d.Attempts)
+	}
+
+	// Check the encoded transaction.
+	enc, _ := d.Tx.MarshalBinary()
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Protected() {
+		t.Error("transaction is replay-protected")
+	}
+	if sender, err := types.Sender(types.HomesteadSigner{}, &tx); err != nil || sender != d.Sender {
+		t.Errorf("wrong decoded sender %v (err %v)", sender, err)
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	gas, err := EstimateGas(initcode4788, "cancun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		intrinsic = 53000 + 5*4 + 101*16 + 4*2 // creation + zero/nonzero bytes + initcode words
+		execution = 3 + 3 + 3 + 2 + (3 + 4*3 + 4*3) + 2
+		deposit   = 97 * 200
+		want      = uint64(intrinsic + execution + deposit)
+	)
+	if gas != want {
+		t.Errorf("wrong gas %d, want %d", gas, want)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package loader
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"github.com/fjl/geas/internal/ast"
+)
+
+// ParseCache stores parsed documents for reuse across compilations.
+// It is safe for concurrent use.
+//
+// There is one entry per file name. The entry also stores a hash of the file content, and
+// is replaced when the file is parsed again with different content. This keeps the size
+// of the cache bounded by the number of files.
+type ParseCache struct {
+	mu      sync.Mutex
+	entries map[string]*parseCacheEntry
+}
+
+type parseCacheEntry struct {
+	hash   [32]byte
+	doc    *ast.Document
+	errors []*ast.ParseError
+}
+
+// NewParseCache creates an empty cache.
+func NewParseCache() *ParseCache {
+	return &ParseCache{entries: make(map[string]*parseCacheEntry)}
+}
+
+// Len returns the number of cached files.
+func (c *ParseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear removes all entries.
+func (c *ParseCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// parse returns the document of a file. The document is a private copy which can be
+// modified by the caller.
+func (c *ParseCache) parse(filename string, content []byte) (*ast.Document, []*ast.ParseError) {
+	hash := sha256.Sum256(content)
+	c.mu.Lock()
+	e := c.entries[filename]
+	c.mu.Unlock()
+
+	if e == nil || e.hash != hash {
+		// Note parsing happens outside of the lock. When the same file is requested
+		// concurrently, it may be parsed more than once.
+		doc, errs := ast.NewParser(filename, content).Parse()
+		e = &parseCacheEntry{hash: hash, doc: doc, errors: errs}
+		c.mu.Lock()
+		c.entries[filename] = e
+		c.mu.Unlock()
+	}
+	if len(e.errors) > 0 {
+		return nil, e.errors
+	}
+	return e.doc.Clone(), nil
+}
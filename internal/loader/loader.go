@@ -32,6 +32,7 @@ type Loader struct {
 	maxIncDepth int
 	defaultFork string
 	errors      *ErrorList
+	cache       *ParseCache
 }
 
 func New(fsys fs.FS) *Loader {
@@ -61,6 +62,16 @@ func (l *Loader) Filesystem() fs.FS {
 	return l.fsys
 }
 
+// SetParseCache sets the cache of parsed documents.
+// If set to nil, all files are parsed on every load.
+func (l *Loader) SetParseCache(c *ParseCache) {
+	l.cache = c
+}
+
+func (l *Loader) ParseCache() *ParseCache {
+	return l.cache
+}
+
 // SetDefaultFork sets the EVM instruction set used by default.
 func (l *Loader) SetDefaultFork(f string) {
 	l.defaultFork = f
@@ -81,8 +92,7 @@ func (l *Loader) LoadFile(filename string) *Program {
 }
 
 func (l *Loader) LoadSource(filename string, src []byte) *Program {
-	p := ast.NewParser(filename, src)
-	doc, pErr := p.Parse()
+	doc, pErr := l.parse(filename, src)
 	if l.errors.addParseErrors(pErr) {
 		return nil
 	}
@@ -196,8 +206,7 @@ func (l *Loader) parseIncludeFile(file string, st *ast.Include, depth int) *ast.
 		return nil
 	}
 
-	p := ast.NewParser(file, content)
-	doc, errors := p.Parse()
+	doc, errors := l.parse(file, content)
 	if l.errors.addParseErrors(errors) {
 		return nil
 	}
@@ -210,6 +219,14 @@ func (l *Loader) parseIncludeFile(file string, st *ast.Include, depth int) *ast.
 	return doc
 }
 
+// parse parses a source file, using the cache if available.
+func (l *Loader) parse(filename string, content []byte) (*ast.Document, []*ast.ParseError) {
+	if l.cache != nil {
+		return l.cache.parse(filename, content)
+	}
+	return ast.NewParser(filename, content).Parse()
+}
+
 func ResolveRelative(basepath string, filename string) (string, error) {
 	res := path.Clean(path.Join(path.Dir(basepath), filename))
 	if res == ".." || strings.HasPrefix(res, "../") {
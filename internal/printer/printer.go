@@ -48,6 +48,7 @@ type Printer struct {
 
 	// Caches for automatic comment column.
 	preFormatCache map[ast.Statement]string
+	preFormatLines []int // lengths of lines with a line comment
 	macroDefLength map[*ast.InstructionMacroDef]int
 
 	// Settings
@@ -79,6 +80,7 @@ func (p *Printer) reset(w io.Writer) {
 	p.bufferWrapped = true
 	p.lineLength = 0
 	p.preFormatCache = make(map[ast.Statement]string)
+	p.preFormatLines = nil
 	p.macroDefLength = make(map[*ast.InstructionMacroDef]int)
 
 	if !p.indentSet {
@@ -221,14 +223,18 @@ func (p *Printer) expr(e ast.Expr, parent ast.Expr) {
 // document writes a document to the output.
 func (p *Printer) document(doc *ast.Document) {
 	// Now print all statements.
-	for _, st := range doc.Statements {
+	for i, st := range doc.Statements {
 		// Add blank line before a block of statements.
-		if st.StartsBlock() {
+		if st.StartsBlock() && p.lineLength == 0 {
 			p.newline()
 		}
 
 		// Write the statement itself.
 		p.statement(st)
+		if inlineLabel(doc, i) {
+			p.byte(' ')
+			continue
+		}
 
 		// Print line comment.
 		if st.Comment() != nil {
@@ -250,7 +256,10 @@ func (p *Printer) preFormat(doc *ast.Document) {
 	var b bytes.Buffer
 	p.out, p.bufferWrapped = &b, false
 
-	for _, st := range doc.Statements {
+	var prefix string // text of inline label
+	for i, st := range doc.Statements {
+		b.Reset()
+		p.lineLength = len(prefix)
 		switch st := st.(type) {
 		case *ast.Comment, *ast.ExpressionMacroDef:
 			continue
@@ -259,7 +268,6 @@ func (p *Printer) preFormat(doc *ast.Document) {
 			if macroHasIndentedStartComment(st) {
 				// For macros with a start comment, store the length of the header so
 				// computeCommentColumn can take it into account.
-				b.Reset()
 				p.macroDefinitionHead(st)
 				p.macroDefLength[st] = b.Len()
 			}
@@ -267,21 +275,40 @@ func (p *Printer) preFormat(doc *ast.Document) {
 			p.preFormat(st.Body)
 
 		default:
-			if st.Comment() == nil {
+			if inlineLabel(doc, i) {
+				p.statement(st)
+				prefix += b.String() + " "
 				continue
 			}
-			b.Reset()
-			p.statement(st)
-			p.preFormatCache[st] = b.String()
+			if st.Comment() != nil {
+				p.statement(st)
+				p.preFormatCache[st] = b.String()
+				p.preFormatLines = append(p.preFormatLines, len(prefix)+b.Len())
+			}
 		}
+		prefix = ""
+	}
+}
+
+// inlineLabel reports whether statement i of the document is a PC label which is
+// printed on the same line as the next statement.
+func inlineLabel(doc *ast.Document, i int) bool {
+	l, ok := doc.Statements[i].(*ast.PCLabel)
+	if !ok || !l.Inline || i+1 >= len(doc.Statements) {
+		return false
+	}
+	switch doc.Statements[i+1].(type) {
+	case *ast.Comment, *ast.ExpressionMacroDef, *ast.InstructionMacroDef:
+		return false
 	}
+	return true
 }
 
 // computeCommentColumn computes a column to which line comments will be indented.
 func (p *Printer) computeCommentColumn() int {
 	autocol := autoCommentColMin
-	for _, entry := range p.preFormatCache {
-		col := len(entry) + commentGap
+	for _, length := range p.preFormatLines {
+		col := length + commentGap
 		if col > autocol && col < autoCommentColMax {
 			autocol = col
 		}
@@ -305,7 +332,7 @@ func (p *Printer) statement(st ast.Statement) {
 	switch st := st.(type) {
 	case *ast.Opcode:
 		// TODO: add option to set lowercase/uppercase
-		p.string(p.indent)
+		p.lineIndent()
 		p.string(st.Op)
 		p.immediatesList(st.Immediates)
 		if st.Arg != nil {
@@ -365,7 +392,7 @@ func (p *Printer) statement(st ast.Statement) {
 		p.byte('}')
 
 	case *ast.InstructionMacroCall:
-		p.string(p.indent)
+		p.lineIndent()
 		p.byte('%')
 		p.string(st.Ident)
 		p.argumentList(st.Args)
@@ -385,6 +412,13 @@ func macroHasIndentedStartComment(st *ast.InstructionMacroDef) bool {
 	return st.StartComment != nil && (st.StartComment.Level() == 1 || st.StartComment.IsStackComment())
 }
 
+// lineIndent writes the indentation prefix, unless the line already has content.
+func (p *Printer) lineIndent() {
+	if p.lineLength == 0 {
+		p.string(p.indent)
+	}
+}
+
 // macroDefinitionHead writes the beginning of a macro definition.
 func (p *Printer) macroDefinitionHead(st *ast.InstructionMacroDef) {
 	p.string("#define %")
@@ -0,0 +1,192 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package salt searches CREATE2 salts which produce addresses matching a pattern.
+package salt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Pattern is the desired form of an address. The prefix and suffix are given in hex
+// digits, so they can have odd length.
+type Pattern struct {
+	Prefix string
+	Suffix string
+}
+
+// ParsePattern validates the prefix and suffix.
+func ParsePattern(prefix, suffix string) (Pattern, error) {
+	p := Pattern{
+		Prefix: strings.ToLower(strings.TrimPrefix(prefix, "0x")),
+		Suffix: strings.ToLower(strings.TrimPrefix(suffix, "0x")),
+	}
+	for _, s := range []string{p.Prefix, p.Suffix} {
+		for _, c := range s {
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				return p, fmt.Errorf("invalid hex digit %q in pattern", c)
+			}
+		}
+	}
+	if len(p.Prefix)+len(p.Suffix) > 2*common.AddressLength {
+		return p, fmt.Errorf("pattern is longer than an address")
+	}
+	return p, nil
+}
+
+// Difficulty returns the expected number of tries to find a matching address.
+func (p Pattern) Difficulty() float64 {
+	d := 1.0
+	for range len(p.Prefix) + len(p.Suffix) {
+		d *= 16
+	}
+	return d
+}
+
+// Match reports whether the address matches the pattern.
+func (p Pattern) Match(addr common.Address) bool {
+	var buf [2 * common.AddressLength]byte
+	hex.Encode(buf[:], addr[:])
+	s := string(buf[:])
+	return strings.HasPrefix(s, p.Prefix) && strings.HasSuffix(s, p.Suffix)
+}
+
+// matcher is an allocation-free version of Pattern.Match.
+type matcher struct {
+	prefix, suffix []byte // nibbles
+}
+
+func (p Pattern) matcher() matcher {
+	nibbles := func(s string) []byte {
+		n := make([]byte, len(s))
+		for i := range s {
+			v, _ := hex.DecodeString("0" + s[i:i+1])
+			n[i] = v[0]
+		}
+		return n
+	}
+	return matcher{prefix: nibbles(p.Prefix), suffix: nibbles(p.Suffix)}
+}
+
+func nibble(addr []byte, i int) byte {
+	if i%2 == 0 {
+		return addr[i/2] >> 4
+	}
+	return addr[i/2] & 0xf
+}
+
+func (m *matcher) match(addr []byte) bool {
+	for i, n := range m.prefix {
+		if nibble(addr, i) != n {
+			return false
+		}
+	}
+	end := 2*common.AddressLength - len(m.suffix)
+	for i, n := range m.suffix {
+		if nibble(addr, end+i) != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Result is a found salt.
+type Result struct {
+	Salt    common.Hash
+	Address common.Address
+	Tries   uint64 // number of salts tried by all workers
+}
+
+// Search tries salts until one produces a matching address for the given deployer and
+// initcode hash. The search runs on the given number of goroutines, and stops when ctx
+// is canceled.
+//
+// The salts tried are start, start+1, start+2, ..., where the counter occupies the last
+// eight bytes of the salt.
+func Search(ctx context.Context, deployer common.Address, initcodeHash common.Hash, pattern Pattern, start common.Hash, workers int) (*Result, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		m      = pattern.matcher()
+		tries  atomic.Uint64
+		once   sync.Once
+		result *Result
+		wg     sync.WaitGroup
+	)
+	base := binary.BigEndian.Uint64(start[24:])
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var (
+				buf  [1 + 20 + 32 + 32]byte
+				hash common.Hash
+				kh   = crypto.NewKeccakState()
+				n    uint64
+			)
+			buf[0] = 0xff
+			copy(buf[1:], deployer[:])
+			copy(buf[21:], start[:])
+			copy(buf[53:], initcodeHash[:])
+			for i := uint64(w); ; i += uint64(workers) {
+				// Check for cancellation once in a while.
+				if n++; n%4096 == 0 {
+					tries.Add(4096)
+					if ctx.Err() != nil {
+						return
+					}
+				}
+				binary.BigEndian.PutUint64(buf[45:53], base+i)
+				kh.Reset()
+				kh.Write(buf[:])
+				kh.Read(hash[:])
+				if m.match(hash[12:]) {
+					once.Do(func() {
+						result = &Result{Address: common.BytesToAddress(hash[12:])}
+						copy(result.Salt[:], buf[21:53])
+						cancel()
+					})
+					tries.Add(n % 4096)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if result == nil {
+		return nil, ctx.Err()
+	}
+	result.Tries = tries.Load()
+	return result, nil
+}
+
+// Address computes the CREATE2 address.
+func Address(deployer common.Address, salt common.Hash, initcodeHash common.Hash) common.Address {
+	return crypto.CreateAddress2(deployer, salt, initcodeHash[:])
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package salt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPattern(t *testing.T) {
+	addr := common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+	tests := []struct {
+		prefix, suffix string
+		match          bool
+	}{
+		{"", "", true},
+		{"4e5", "", true},
+		{"0x4E59", "956c", true},
+		{"", "56c", true},
+		{"4f", "", false},
+		{"", "956d", false},
+		{"4e59b44847b379578588920ca78fbf26c0b4956c", "", true},
+	}
+	for _, test := range tests {
+		p, err := ParsePattern(test.prefix, test.suffix)
+		if err != nil {
+			t.Fatalf("%q/%q: %v", test.prefix, test.suffix, err)
+		}
+		if p.Match(addr) != test.match {
+			t.Errorf("%q/%q: Match = %v, want %v", test.prefix, test.suffix, !test.match, test.match)
+		}
+		m := p.matcher()
+		if m.match(addr[:]) != test.match {
+			t.Errorf("%q/%q: matcher = %v, want %v", test.prefix, test.suffix, !test.match, test.match)
+		}
+	}
+
+	for _, bad := range []string{"xyz", "0x12g", "00000000000000000000000000000000000000000"} {
+		if _, err := ParsePattern(bad, ""); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var (
+		deployer = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+		inithash = crypto.Keccak256Hash([]byte{0x60, 0x00})
+	)
+	p, _ := ParsePattern("00", "f")
+	res, err := Search(context.Background(), deployer, inithash, p, common.Hash{}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Match(res.Address) {
+		t.Fatalf("result address %v does not match pattern", res.Address)
+	}
+	if addr := crypto.CreateAddress2(deployer, res.Salt, inithash[:]); addr != res.Address {
+		t.Fatalf("wrong address %v, salt %v gives %v", res.Address, res.Salt, addr)
+	}
+	if res.Tries == 0 {
+		t.Fatal("zero tries reported")
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p, _ := ParsePattern("0000000000000000", "")
+	_, err := Search(ctx, common.Address{}, common.Hash{}, p, common.Hash{}, 2)
+	if err != context.Canceled {
+		t.Fatalf("wrong error %v", err)
+	}
+}
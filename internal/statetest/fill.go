@@ -0,0 +1,509 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package statetest
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/loader"
+	"github.com/fjl/geas/internal/testfile"
+	"github.com/holiman/uint256"
+)
+
+// Defaults of the test environment and transaction, as used by ethereum/tests.
+var (
+	defaultEnv = Env{
+		Coinbase:      "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+		Difficulty:    "0x020000",
+		Random:        "0x0000000000000000000000000000000000000000000000000000000000020000",
+		GasLimit:      "0x05f5e100",
+		Number:        "0x01",
+		Timestamp:     "0x03e8",
+		BaseFee:       "0x0a",
+		ExcessBlobGas: "0x00",
+	}
+	defaultSecretKey = "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+	defaultGasPrice  = "0x0a"
+	defaultGas       = "1000000"
+	senderBalance    = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+)
+
+// Test is a state test in the GeneralStateTest JSON format.
+type Test struct {
+	Info        map[string]string      `json:"_info"`
+	Env         map[string]string      `json:"env"`
+	Pre         map[string]jsonAccount `json:"pre"`
+	Transaction jsonTransaction        `json:"transaction"`
+	Post        map[string][]PostState `json:"post"`
+}
+
+// PostState is the expected outcome of the transaction in a fork.
+type PostState struct {
+	Hash    common.Hash   `json:"hash"`
+	Logs    common.Hash   `json:"logs"`
+	TxBytes hexutil.Bytes `json:"txbytes"`
+	Indexes Indexes       `json:"indexes"`
+}
+
+// Indexes selects the transaction data, gas limit and value of a post-state.
+type Indexes struct {
+	Data  int `json:"data"`
+	Gas   int `json:"gas"`
+	Value int `json:"value"`
+}
+
+type jsonAccount struct {
+	Balance string            `json:"balance"`
+	Code    string            `json:"code"`
+	Nonce   string            `json:"nonce"`
+	Storage map[string]string `json:"storage"`
+}
+
+type jsonTransaction struct {
+	Data                 []string `json:"data"`
+	GasLimit             []string `json:"gasLimit"`
+	GasPrice             string   `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string   `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string   `json:"maxPriorityFeePerGas,omitempty"`
+	Nonce                string   `json:"nonce"`
+	SecretKey            string   `json:"secretKey"`
+	Sender               string   `json:"sender"`
+	To                   string   `json:"to"`
+	Value                []string `json:"value"`
+}
+
+// Fill compiles the contracts of the pre-state, and runs the transaction in all forks
+// to compute the post-state root and logs hash. Source files are read from fsys.
+//
+// All forks must contain the instruction sets the contracts are compiled for. Contracts
+// without #pragma target are compiled for the first fork.
+func (s *Spec) Fill(fsys fs.FS) (map[string]*Test, error) {
+	test := &Test{
+		Info: map[string]string{"source": s.Path, "filling-tool": "geas"},
+		Pre:  make(map[string]jsonAccount),
+		Post: make(map[string][]PostState),
+	}
+	if s.Comment != "" {
+		test.Info["comment"] = s.Comment
+	}
+	testForks := make([]string, len(s.Forks))
+	for i, fork := range s.Forks {
+		var err error
+		if testForks[i], err = TestForkName(fork); err != nil {
+			return nil, err
+		}
+	}
+	test.Env = s.Env.json()
+
+	// Build the pre-state.
+	for _, addrText := range slices.Sorted(maps.Keys(s.Pre)) {
+		if !common.IsHexAddress(addrText) {
+			return nil, fmt.Errorf("invalid address %q in pre-state", addrText)
+		}
+		addr := common.HexToAddress(addrText)
+		acc, err := s.account(fsys, addrText, s.Pre[addrText])
+		if err != nil {
+			return nil, err
+		}
+		test.Pre[hexAddress(addr)] = acc
+	}
+
+	// Create the transaction.
+	tx, key, err := s.Tx.json()
+	if err != nil {
+		return nil, err
+	}
+	test.Transaction = tx
+	if _, ok := test.Pre[tx.Sender]; !ok {
+		test.Pre[tx.Sender] = jsonAccount{
+			Balance: hexNumber(senderBalance),
+			Code:    "0x",
+			Nonce:   "0x00",
+			Storage: map[string]string{},
+		}
+	}
+
+	// Sign and run the transactions. Note the signature depends on the fork, because
+	// replay-protected signatures are not valid before EIP-155.
+	for i, fork := range s.Forks {
+		config, err := evmrun.ChainConfig(fork)
+		if err != nil {
+			return nil, err
+		}
+		signer := types.LatestSigner(config)
+		for d := range tx.Data {
+			for g := range tx.GasLimit {
+				for v := range tx.Value {
+					idx := Indexes{Data: d, Gas: g, Value: v}
+					post := PostState{Indexes: idx}
+					if post.TxBytes, err = tx.sign(idx, signer, key); err != nil {
+						return nil, err
+					}
+					if post.Hash, post.Logs, err = test.run(config, signer, post.TxBytes); err != nil {
+						return nil, fmt.Errorf("%s: transaction with data %d, gas %d, value %d failed: %v", testForks[i], d, g, v, err)
+					}
+					test.Post[testForks[i]] = append(test.Post[testForks[i]], post)
+				}
+			}
+		}
+	}
+	return map[string]*Test{s.Name: test}, nil
+}
+
+// account creates the JSON representation of a pre-state account.
+func (s *Spec) account(fsys fs.FS, addr string, acc *Account) (jsonAccount, error) {
+	if acc == nil {
+		acc = new(Account)
+	}
+	res := jsonAccount{Code: "0x", Storage: make(map[string]string)}
+	var err error
+	if res.Balance, err = parseNumber(acc.Balance); err != nil {
+		return res, fmt.Errorf("account %s: invalid balance: %v", addr, err)
+	}
+	if res.Nonce, err = parseNumber(acc.Nonce); err != nil {
+		return res, fmt.Errorf("account %s: invalid nonce: %v", addr, err)
+	}
+	storage, err := testfile.ParseStorage(acc.Storage)
+	if err != nil {
+		return res, fmt.Errorf("account %s: %v", addr, err)
+	}
+	for k, v := range storage {
+		res.Storage[hexNumber(k.Big())] = hexNumber(v.Big())
+	}
+
+	switch {
+	case acc.Source != "" && acc.Code != "":
+		return res, fmt.Errorf("account %s: both source and code are set", addr)
+	case acc.Source != "":
+		code, err := s.compile(fsys, acc.Source)
+		if err != nil {
+			return res, fmt.Errorf("account %s: %v", addr, err)
+		}
+		res.Code = hexutil.Encode(code)
+	case acc.Code != "":
+		code, err := testfile.ParseHex(acc.Code)
+		if err != nil {
+			return res, fmt.Errorf("account %s: invalid code: %v", addr, err)
+		}
+		res.Code = hexutil.Encode(code)
+	}
+	return res, nil
+}
+
+// compile compiles a contract and checks it can run in all forks of the test.
+func (s *Spec) compile(fsys fs.FS, source string) ([]byte, error) {
+	file, err := loader.ResolveRelative(s.Path, source)
+	if err != nil {
+		return nil, err
+	}
+	c := asm.New(fsys)
+	c.SetDefaultFork(s.Forks[0])
+	code := c.CompileFile(file)
+	if c.Failed() {
+		return nil, errors.Join(c.Errors()...)
+	}
+	target := c.DebugInfo().Fork
+	for _, fork := range s.Forks {
+		if !includesFork(fork, target) {
+			return nil, fmt.Errorf("%s is compiled for %s, which is not available in fork %s", file, target, fork)
+		}
+	}
+	return code, nil
+}
+
+// run executes a transaction on the pre-state and returns the post-state root and the
+// hash of the logs. The block environment is set up like in the state test runner of
+// go-ethereum.
+func (t *Test) run(config *params.ChainConfig, signer types.Signer, txbytes []byte) (root, logs common.Hash, err error) {
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(txbytes); err != nil {
+		return root, logs, err
+	}
+	statedb, err := t.preState()
+	if err != nil {
+		return root, logs, err
+	}
+
+	var (
+		number = decodeNumber(t.Env["currentNumber"])
+		time   = decodeNumber(t.Env["currentTimestamp"]).Uint64()
+		random = common.BigToHash(decodeNumber(t.Env["currentRandom"]))
+	)
+	ctx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     blockHash,
+		Coinbase:    common.HexToAddress(t.Env["currentCoinbase"]),
+		BlockNumber: number,
+		Time:        time,
+		Difficulty:  decodeNumber(t.Env["currentDifficulty"]),
+		GasLimit:    decodeNumber(t.Env["currentGasLimit"]).Uint64(),
+	}
+	if config.IsLondon(number) {
+		ctx.BaseFee = decodeNumber(t.Env["currentBaseFee"])
+		ctx.Random = &random
+		ctx.Difficulty = new(big.Int)
+	}
+	if config.IsCancun(number, time) {
+		excess := decodeNumber(t.Env["currentExcessBlobGas"]).Uint64()
+		ctx.BlobBaseFee = eip4844.CalcBlobFee(config, &types.Header{Time: time, ExcessBlobGas: &excess})
+	}
+
+	msg, err := core.TransactionToMessage(&tx, signer, ctx.BaseFee)
+	if err != nil {
+		return root, logs, err
+	}
+	evm := vm.NewEVM(ctx, statedb, config, vm.Config{})
+	if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(ctx.GasLimit)); err != nil {
+		return root, logs, err
+	}
+	// Touch the coinbase with a zero reward. This matters when the transaction
+	// does not pay a fee to the coinbase, e.g. when the coinbase self-destructed.
+	statedb.AddBalance(ctx.Coinbase, new(uint256.Int), tracing.BalanceChangeUnspecified)
+
+	enc, err := rlp.EncodeToBytes(statedb.Logs())
+	if err != nil {
+		return root, logs, err
+	}
+	root, err = statedb.Commit(number.Uint64(), config.IsEIP158(number), config.IsCancun(number, time))
+	return root, crypto.Keccak256Hash(enc), err
+}
+
+// preState creates a state database containing the pre-state accounts.
+func (t *Test) preState() (*state.StateDB, error) {
+	db := state.NewDatabaseForTesting()
+	statedb, err := state.New(types.EmptyRootHash, db)
+	if err != nil {
+		return nil, err
+	}
+	for addrText, acc := range t.Pre {
+		addr := common.HexToAddress(addrText)
+		statedb.SetCode(addr, hexutil.MustDecode(acc.Code), tracing.CodeChangeUnspecified)
+		statedb.SetNonce(addr, decodeNumber(acc.Nonce).Uint64(), tracing.NonceChangeUnspecified)
+		statedb.SetBalance(addr, uint256.MustFromBig(decodeNumber(acc.Balance)), tracing.BalanceChangeUnspecified)
+		for k, v := range acc.Storage {
+			statedb.SetState(addr, common.BigToHash(decodeNumber(k)), common.BigToHash(decodeNumber(v)))
+		}
+	}
+	// Commit and re-open to start with a clean state.
+	root, err := statedb.Commit(0, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return state.New(root, db)
+}
+
+// blockHash is the BLOCKHASH function of state tests.
+func blockHash(n uint64) common.Hash {
+	return crypto.Keccak256Hash([]byte(strconv.FormatUint(n, 10)))
+}
+
+func (e Env) json() map[string]string {
+	or := func(v, def string) string {
+		if v == "" {
+			return def
+		}
+		return v
+	}
+	return map[string]string{
+		"currentCoinbase":      or(e.Coinbase, defaultEnv.Coinbase),
+		"currentDifficulty":    or(e.Difficulty, defaultEnv.Difficulty),
+		"currentRandom":        or(e.Random, defaultEnv.Random),
+		"currentGasLimit":      or(e.GasLimit, defaultEnv.GasLimit),
+		"currentNumber":        or(e.Number, defaultEnv.Number),
+		"currentTimestamp":     or(e.Timestamp, defaultEnv.Timestamp),
+		"currentBaseFee":       or(e.BaseFee, defaultEnv.BaseFee),
+		"currentExcessBlobGas": or(e.ExcessBlobGas, defaultEnv.ExcessBlobGas),
+	}
+}
+
+// json creates the JSON representation of the transaction.
+func (tx *Transaction) json() (jsonTransaction, *ecdsa.PrivateKey, error) {
+	var res jsonTransaction
+	keyText := tx.SecretKey
+	if keyText == "" {
+		keyText = defaultSecretKey
+	}
+	key, err := crypto.HexToECDSA(trimHex(keyText))
+	if err != nil {
+		return res, nil, fmt.Errorf("invalid secret key: %v", err)
+	}
+	res.SecretKey = hexutil.Encode(crypto.FromECDSA(key))
+	res.Sender = hexAddress(crypto.PubkeyToAddress(key.PublicKey))
+
+	if res.Nonce, err = parseNumber(tx.Nonce); err != nil {
+		return res, nil, fmt.Errorf("invalid transaction nonce: %v", err)
+	}
+	if tx.To != "" {
+		if !common.IsHexAddress(tx.To) {
+			return res, nil, fmt.Errorf("invalid transaction recipient %q", tx.To)
+		}
+		res.To = hexAddress(common.HexToAddress(tx.To))
+	}
+	if tx.MaxFee != "" {
+		if res.MaxFeePerGas, err = parseNumber(tx.MaxFee); err != nil {
+			return res, nil, fmt.Errorf("invalid max-fee: %v", err)
+		}
+		if res.MaxPriorityFeePerGas, err = parseNumber(tx.MaxPriorityFee); err != nil {
+			return res, nil, fmt.Errorf("invalid max-priority-fee: %v", err)
+		}
+	} else {
+		if res.GasPrice, err = parseNumber(tx.GasPrice); err != nil {
+			return res, nil, fmt.Errorf("invalid gas-price: %v", err)
+		}
+		if tx.GasPrice == "" {
+			res.GasPrice = defaultGasPrice
+		}
+	}
+
+	data := tx.Data
+	if len(data) == 0 {
+		data = list{""}
+	}
+	for i, d := range data {
+		b, err := testfile.ParseHex(d)
+		if err != nil {
+			return res, nil, fmt.Errorf("invalid transaction data %d: %v", i, err)
+		}
+		res.Data = append(res.Data, hexutil.Encode(b))
+	}
+	gas := tx.Gas
+	if len(gas) == 0 {
+		gas = list{defaultGas}
+	}
+	for i, g := range gas {
+		v, err := parseNumber(g)
+		if err != nil {
+			return res, nil, fmt.Errorf("invalid transaction gas %d: %v", i, err)
+		}
+		res.GasLimit = append(res.GasLimit, v)
+	}
+	value := tx.Value
+	if len(value) == 0 {
+		value = list{""}
+	}
+	for i, val := range value {
+		v, err := parseNumber(val)
+		if err != nil {
+			return res, nil, fmt.Errorf("invalid transaction value %d: %v", i, err)
+		}
+		res.Value = append(res.Value, v)
+	}
+	return res, key, nil
+}
+
+// sign creates the signed transaction for a post-state.
+func (tx *jsonTransaction) sign(idx Indexes, signer types.Signer, key *ecdsa.PrivateKey) ([]byte, error) {
+	var (
+		nonce = decodeNumber(tx.Nonce).Uint64()
+		gas   = decodeNumber(tx.GasLimit[idx.Gas]).Uint64()
+		value = decodeNumber(tx.Value[idx.Value])
+		data  = hexutil.MustDecode(tx.Data[idx.Data])
+		to    *common.Address
+	)
+	if tx.To != "" {
+		addr := common.HexToAddress(tx.To)
+		to = &addr
+	}
+	var inner types.TxData
+	if tx.MaxFeePerGas != "" {
+		inner = &types.DynamicFeeTx{
+			ChainID:   signer.ChainID(),
+			Nonce:     nonce,
+			GasTipCap: decodeNumber(tx.MaxPriorityFeePerGas),
+			GasFeeCap: decodeNumber(tx.MaxFeePerGas),
+			Gas:       gas,
+			To:        to,
+			Value:     value,
+			Data:      data,
+		}
+	} else {
+		inner = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: decodeNumber(tx.GasPrice),
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}
+	}
+	signed, err := types.SignNewTx(key, signer, inner)
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+// parseNumber converts a decimal or hex number to even-length hex. The empty string
+// is zero.
+func parseNumber(s string) (string, error) {
+	if s == "" {
+		return "0x00", nil
+	}
+	v, err := testfile.ParseWord(s)
+	if err != nil {
+		return "", err
+	}
+	return hexNumber(v.Big()), nil
+}
+
+// hexNumber encodes a number as even-length hex, which is the format of ethereum/tests.
+func hexNumber(v *big.Int) string {
+	b := v.Bytes()
+	if len(b) == 0 {
+		return "0x00"
+	}
+	return "0x" + hex.EncodeToString(b)
+}
+
+// decodeNumber parses the output of hexNumber.
+func decodeNumber(s string) *big.Int {
+	v, _ := new(big.Int).SetString(trimHex(s), 16)
+	return v
+}
+
+func hexAddress(a common.Address) string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+func trimHex(s string) string {
+	if len(s) >= 2 && (s[:2] == "0x" || s[:2] == "0X") {
+		return s[2:]
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package statetest creates Ethereum state tests from geas programs.
+//
+// A state test is declared in a YAML file, which lists the forks, the pre-state and
+// the transaction:
+//
+//	name: sstoreGas
+//	forks: [cancun, prague]
+//	pre:
+//	  0x00000000000000000000000000000000c0dec0de:
+//	    source: sstore.eas
+//	    storage: {0: 1}
+//	transaction:
+//	  to: 0x00000000000000000000000000000000c0dec0de
+//	  data: [0x, 0x01]
+//	  gas: 100000
+//
+// [Spec.Fill] runs the transaction and writes the test in the GeneralStateTest JSON
+// format used by ethereum/tests.
+package statetest
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/fjl/geas/internal/evm"
+	"gopkg.in/yaml.v3"
+)
+
+// Suffix is the file name suffix of state test definitions.
+const Suffix = "_statetest.yaml"
+
+// Spec is a state test definition.
+type Spec struct {
+	Path    string              `yaml:"-"`
+	Name    string              `yaml:"name,omitempty"`    // defaults to the file name
+	Comment string              `yaml:"comment,omitempty"` // stored in _info
+	Forks   []string            `yaml:"forks,omitempty"`   // defaults to evm.LatestFork
+	Env     Env                 `yaml:"env,omitempty"`
+	Pre     map[string]*Account `yaml:"pre"`
+	Tx      Transaction         `yaml:"transaction"`
+}
+
+// Env is the block environment. Fields that are not set have the default values of
+// ethereum/tests.
+type Env struct {
+	Coinbase      string `yaml:"coinbase,omitempty"`
+	Difficulty    string `yaml:"difficulty,omitempty"`
+	Random        string `yaml:"random,omitempty"`
+	GasLimit      string `yaml:"gas-limit,omitempty"`
+	Number        string `yaml:"number,omitempty"`
+	Timestamp     string `yaml:"timestamp,omitempty"`
+	BaseFee       string `yaml:"base-fee,omitempty"`
+	ExcessBlobGas string `yaml:"excess-blob-gas,omitempty"`
+}
+
+// Account is a pre-state account. The code is given either as hex, or as a geas source
+// file relative to the definition file.
+type Account struct {
+	Source  string            `yaml:"source,omitempty"`
+	Code    string            `yaml:"code,omitempty"`
+	Balance string            `yaml:"balance,omitempty"`
+	Nonce   string            `yaml:"nonce,omitempty"`
+	Storage map[string]string `yaml:"storage,omitempty"`
+}
+
+// Transaction is the transaction of a state test. Data, gas and value can be lists, in
+// which case the test contains a post-state for each combination.
+//
+// The sender is derived from the secret key. When the sender is not part of the
+// pre-state, it is added with a balance of 1000 ether.
+type Transaction struct {
+	SecretKey      string `yaml:"secret-key,omitempty"`
+	Nonce          string `yaml:"nonce,omitempty"`
+	GasPrice       string `yaml:"gas-price,omitempty"`
+	MaxFee         string `yaml:"max-fee,omitempty"`          // creates dynamic fee transaction
+	MaxPriorityFee string `yaml:"max-priority-fee,omitempty"` // for dynamic fee transaction
+	To             string `yaml:"to,omitempty"`               // empty for contract creation
+	Data           list   `yaml:"data,omitempty"`
+	Gas            list   `yaml:"gas,omitempty"`
+	Value          list   `yaml:"value,omitempty"`
+}
+
+// list is a YAML value that can be given as a single item or a sequence.
+type list []string
+
+func (l *list) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = list{node.Value}
+		return nil
+	}
+	var items []string
+	if err := node.Decode(&items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+// Load reads a state test definition.
+func Load(fsys fs.FS, file string) (*Spec, error) {
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	spec := new(Spec)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	spec.Path = file
+	if spec.Name == "" {
+		base := path.Base(file)
+		spec.Name = strings.TrimSuffix(strings.TrimSuffix(base, Suffix), path.Ext(base))
+	}
+	if len(spec.Forks) == 0 {
+		spec.Forks = []string{evm.LatestFork}
+	}
+	return spec, nil
+}
+
+// forkNames maps geas instruction set names to the fork names of ethereum/tests.
+var forkNames = map[string]string{
+	"frontier":         "Frontier",
+	"homestead":        "Homestead",
+	"tangerinewhistle": "EIP150",
+	"spuriousdragon":   "EIP158",
+	"byzantium":        "Byzantium",
+	"constantinople":   "ConstantinopleFix",
+	"istanbul":         "Istanbul",
+	"berlin":           "Berlin",
+	"london":           "London",
+	"paris":            "Paris",
+	"shanghai":         "Shanghai",
+	"cancun":           "Cancun",
+	"prague":           "Prague",
+	"osaka":            "Osaka",
+}
+
+// TestForkName returns the ethereum/tests name of a fork.
+func TestForkName(fork string) (string, error) {
+	is := evm.FindInstructionSet(fork)
+	if is == nil {
+		return "", fmt.Errorf("unknown fork %q", fork)
+	}
+	name, ok := forkNames[is.Name()]
+	if !ok {
+		return "", fmt.Errorf("fork %q is not supported by state tests", fork)
+	}
+	return name, nil
+}
+
+// includesFork reports whether the instruction set of fork contains all instructions
+// of target, i.e. whether fork is target or a descendant of it.
+func includesFork(fork, target string) bool {
+	is := evm.FindInstructionSet(fork)
+	t := evm.FindInstructionSet(target)
+	if is == nil || t == nil {
+		return false
+	}
+	if is.Name() == t.Name() {
+		return true
+	}
+	for _, p := range is.Parents() {
+		if p == t.Name() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package statetest
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var testFS = fstest.MapFS{
+	"st/sstore.eas": {Data: []byte(`
+	push 0
+	calldataload            ; [x]
+	push 1                  ; [slot, x]
+	sstore                  ; []
+	push 0
+	push 0
+	log0
+`)},
+	"st/push0.eas": {Data: []byte(`
+#pragma target "shanghai"
+	push0
+	push0
+	sstore
+`)},
+	"st/sstore_statetest.yaml": {Data: []byte(`
+comment: store calldata
+forks: [berlin, cancun]
+pre:
+  0x00000000000000000000000000000000c0dec0de:
+    source: sstore.eas
+    storage: {0x01: 0xff}
+transaction:
+  to: 0x00000000000000000000000000000000c0dec0de
+  data: [0x, 0x0000000000000000000000000000000000000000000000000000000000000005]
+  gas: 100000
+`)},
+	"st/push0_statetest.yaml": {Data: []byte(`
+forks: [london, cancun]
+pre:
+  0x00000000000000000000000000000000c0dec0de:
+    source: push0.eas
+transaction:
+  to: 0x00000000000000000000000000000000c0dec0de
+`)},
+}
+
+func TestFill(t *testing.T) {
+	spec, err := Load(testFS, "st/sstore_statetest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "sstore" {
+		t.Errorf("wrong name %q", spec.Name)
+	}
+	filled, err := spec.Fill(testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := filled["sstore"]
+	if len(test.Post["Berlin"]) != 2 || len(test.Post["Cancun"]) != 2 {
+		t.Fatalf("wrong post states %v", test.Post)
+	}
+	if test.Post["Cancun"][0].Hash == test.Post["Cancun"][1].Hash {
+		t.Error("post-state roots are equal for different inputs")
+	}
+	if test.Info["comment"] != "store calldata" {
+		t.Errorf("wrong info %v", test.Info)
+	}
+
+	// The expected values were computed by the go-ethereum state test runner.
+	wantHashes := map[string][]common.Hash{
+		"Berlin": {
+			common.HexToHash("0x511546fad68990fc487f174676d2e823fbcdc6a4e52575dfd5121af88e4241f8"),
+			common.HexToHash("0x0b2ff140548c44d2c6b5ca07b37b0741f9bd235635cd61511a524ebdf1ab48e6"),
+		},
+		"Cancun": {
+			common.HexToHash("0x731703df937d515ff2a7d2dc0815c90f11540c4ab6d7dbc23a4b5b9cefdfe3b7"),
+			common.HexToHash("0x9f5bb3b062ca53e1c874bdd63d68cf5afd12575b44e12daf0fb543409b07dcd6"),
+		},
+	}
+	wantLogs := common.HexToHash("0x3f332430a751d819e556580f4fe6f9c8ee7b6ab8f3ed6eb9a404aac2fc65ebec")
+	for fork, hashes := range wantHashes {
+		for i, want := range hashes {
+			post := test.Post[fork][i]
+			if post.Hash != want {
+				t.Errorf("%s/%d: wrong post-state root %v, want %v", fork, i, post.Hash, want)
+			}
+			if post.Logs != wantLogs {
+				t.Errorf("%s/%d: wrong logs hash %v, want %v", fork, i, post.Logs, wantLogs)
+			}
+		}
+	}
+}
+
+func TestForkValidation(t *testing.T) {
+	spec, err := Load(testFS, "st/push0_statetest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = spec.Fill(testFS)
+	want := "st/push0.eas is compiled for shanghai, which is not available in fork london"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("wrong error %v", err)
+	}
+
+	spec.Forks = []string{"cancun", "tron"}
+	if _, err = spec.Fill(testFS); err == nil || err.Error() != `fork "tron" is not supported by state tests` {
+		t.Fatalf("wrong error %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package structlog reads EVM execution traces in the struct log format.
+//
+// Two encodings are supported: the result of debug_traceTransaction with the default
+// tracer, which is a JSON object containing a "structLogs" array, and the output of
+// 'evm --json', which contains one JSON object per line.
+package structlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// Step is an execution step.
+type Step struct {
+	PC      uint64
+	Op      vm.OpCode
+	Gas     uint64
+	GasCost uint64
+	Depth   int
+	Stack   []uint256.Int // top first
+	Error   string
+}
+
+// stepJSON is the encoding of a step. Numbers can be given as JSON numbers or hex strings.
+type stepJSON struct {
+	PC      *number  `json:"pc"`
+	Op      *opField `json:"op"`
+	OpName  string   `json:"opName"`
+	Gas     number   `json:"gas"`
+	GasCost number   `json:"gasCost"`
+	Depth   number   `json:"depth"`
+	Stack   []string `json:"stack"`
+	Error   string   `json:"error"`
+}
+
+// Read parses a trace.
+func Read(r io.Reader) ([]Step, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	input = bytes.TrimSpace(input)
+
+	// Try the debug_traceTransaction format first. It is either the bare
+	// result object, or a JSON-RPC response containing it.
+	var result struct {
+		StructLogs []stepJSON `json:"structLogs"`
+		Result     *struct {
+			StructLogs []stepJSON `json:"structLogs"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(input, &result); err == nil {
+		switch {
+		case result.StructLogs != nil:
+			return convertSteps(result.StructLogs)
+		case result.Result != nil && result.Result.StructLogs != nil:
+			return convertSteps(result.Result.StructLogs)
+		}
+	}
+
+	// Parse as JSON lines. Lines which are not execution steps, such as
+	// the summary at the end, are skipped.
+	var steps []stepJSON
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] != '{' {
+			continue
+		}
+		var step stepJSON
+		if err := json.Unmarshal(text, &step); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if step.PC != nil {
+			steps = append(steps, step)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("no execution steps found in trace")
+	}
+	return convertSteps(steps)
+}
+
+func convertSteps(input []stepJSON) ([]Step, error) {
+	steps := make([]Step, len(input))
+	for i, s := range input {
+		if s.PC == nil {
+			return nil, fmt.Errorf("step %d: missing pc", i)
+		}
+		steps[i] = Step{
+			PC:      uint64(*s.PC),
+			Gas:     uint64(s.Gas),
+			GasCost: uint64(s.GasCost),
+			Depth:   int(s.Depth),
+			Error:   s.Error,
+		}
+		switch {
+		case s.Op != nil && s.Op.isNum:
+			steps[i].Op = vm.OpCode(s.Op.num)
+		case s.Op != nil:
+			steps[i].Op = vm.StringToOp(s.Op.name)
+		case s.OpName != "":
+			steps[i].Op = vm.StringToOp(s.OpName)
+		default:
+			return nil, fmt.Errorf("step %d: missing op", i)
+		}
+		// The stack is encoded bottom first.
+		steps[i].Stack = make([]uint256.Int, len(s.Stack))
+		for j, item := range s.Stack {
+			v, err := uint256.FromHex(normalizeHex(item))
+			if err != nil {
+				return nil, fmt.Errorf("step %d: invalid stack item %q", i, item)
+			}
+			steps[i].Stack[len(s.Stack)-1-j] = *v
+		}
+	}
+	return steps, nil
+}
+
+// normalizeHex converts a hex number to the canonical form accepted by uint256.
+// Older geth versions output stack items without 0x prefix and with leading zeros.
+func normalizeHex(s string) string {
+	s = strings.TrimPrefix(s, "0x")
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		s = "0"
+	}
+	return "0x" + s
+}
+
+// number is a JSON number or hex string.
+type number uint64
+
+func (n *number) UnmarshalJSON(input []byte) error {
+	var v uint64
+	var err error
+	if len(input) > 0 && input[0] == '"' {
+		var s string
+		if err = json.Unmarshal(input, &s); err != nil {
+			return err
+		}
+		v, err = strconv.ParseUint(s, 0, 64)
+	} else {
+		v, err = strconv.ParseUint(string(input), 10, 64)
+	}
+	*n = number(v)
+	return err
+}
+
+// opField is the op of a step, which is an opcode name or number.
+type opField struct {
+	isNum bool
+	num   byte
+	name  string
+}
+
+func (op *opField) UnmarshalJSON(input []byte) error {
+	if len(input) > 0 && input[0] == '"' {
+		return json.Unmarshal(input, &op.name)
+	}
+	op.isNum = true
+	return json.Unmarshal(input, &op.num)
+}
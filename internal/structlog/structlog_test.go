@@ -0,0 +1,127 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package structlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+const testProgram = `
+	push 2
+	push 1
+	add
+	push 0
+	mstore
+	push 32
+	push 0
+	return
+`
+
+var testProgramOps = []vm.OpCode{vm.PUSH1, vm.PUSH1, vm.ADD, vm.PUSH0, vm.MSTORE, vm.PUSH1, vm.PUSH0, vm.RETURN}
+
+func compileTestProgram(t *testing.T) []byte {
+	c := asm.New(nil)
+	code := c.CompileString(testProgram)
+	if c.Failed() {
+		t.Fatal(c.Errors())
+	}
+	return code
+}
+
+func checkSteps(t *testing.T, steps []Step) {
+	t.Helper()
+	if len(steps) != len(testProgramOps) {
+		t.Fatalf("wrong number of steps %d", len(steps))
+	}
+	for i, op := range testProgramOps {
+		if steps[i].Op != op {
+			t.Errorf("step %d: wrong op %v, want %v", i, steps[i].Op, op)
+		}
+		if steps[i].Depth != 1 {
+			t.Errorf("step %d: wrong depth %d", i, steps[i].Depth)
+		}
+	}
+	add := steps[2]
+	if add.PC != 4 || add.GasCost != 3 || len(add.Stack) != 2 {
+		t.Errorf("wrong ADD step %+v", add)
+	}
+	if add.Stack[0].Uint64() != 1 || add.Stack[1].Uint64() != 2 {
+		t.Errorf("wrong stack order in ADD step %v", add.Stack)
+	}
+	if steps[1].Gas-steps[2].Gas != 3 {
+		t.Errorf("wrong gas in steps")
+	}
+}
+
+func TestReadJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := evmrun.Run(compileTestProgram(t), evmrun.Config{
+		Tracer: logger.NewJSONLogger(&logger.Config{}, &buf),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, steps)
+}
+
+func TestReadStructLogs(t *testing.T) {
+	l := logger.NewStructLogger(&logger.Config{})
+	_, err := evmrun.Run(compileTestProgram(t), evmrun.Config{Tracer: l.Hooks()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := l.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bare result.
+	steps, err := Read(bytes.NewReader(result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, steps)
+
+	// JSON-RPC response.
+	resp, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "result": result})
+	steps, err = Read(bytes.NewReader(resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSteps(t, steps)
+}
+
+func TestReadInvalid(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"output":"","gasUsed":"0x0"}`)); err == nil {
+		t.Error("expected error for trace without steps")
+	}
+	if _, err := Read(strings.NewReader(`{"pc":0,"op":"PUSH1","stack":["zz"]}`)); err == nil {
+		t.Error("expected error for invalid stack item")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package testfile
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fjl/geas/internal/evmrun"
+)
+
+// Call contains the inputs of an execution.
+type Call struct {
+	Input   string            `yaml:"input,omitempty"`   // calldata as hex
+	Value   string            `yaml:"value,omitempty"`   // callvalue
+	Caller  string            `yaml:"caller,omitempty"`  // caller address
+	Gas     uint64            `yaml:"gas,omitempty"`     // gas limit
+	Storage map[string]string `yaml:"storage,omitempty"` // initial storage
+}
+
+// Config converts the call to an execution config.
+func (c *Call) Config(fork string) (evmrun.Config, error) {
+	cfg := evmrun.Config{Fork: fork, GasLimit: c.Gas}
+	var err error
+	if cfg.Input, err = ParseHex(c.Input); err != nil {
+		return cfg, fmt.Errorf("invalid input: %v", err)
+	}
+	if c.Value != "" {
+		v, err := ParseWord(c.Value)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid value: %v", err)
+		}
+		cfg.Value = v.Big()
+	}
+	if c.Caller != "" {
+		if !common.IsHexAddress(c.Caller) {
+			return cfg, fmt.Errorf("invalid caller %q", c.Caller)
+		}
+		cfg.Caller = common.HexToAddress(c.Caller)
+	}
+	if len(c.Storage) > 0 {
+		if cfg.Storage, err = ParseStorage(c.Storage); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// ParseStorage converts a map of storage slots.
+func ParseStorage(m map[string]string) (map[common.Hash]common.Hash, error) {
+	storage := make(map[common.Hash]common.Hash, len(m))
+	for k, v := range m {
+		slot, err := ParseWord(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage slot: %v", err)
+		}
+		value, err := ParseWord(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage value: %v", err)
+		}
+		storage[slot] = value
+	}
+	return storage, nil
+}
+
+// ParseHex decodes hex with optional 0x prefix. Whitespace in the input is ignored,
+// which allows splitting long values across lines.
+func ParseHex(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return hex.DecodeString(s)
+}
+
+// ParseWord parses a 256-bit number, given in decimal or as 0x-prefixed hex.
+func ParseWord(s string) (common.Hash, error) {
+	v, ok := new(big.Int).SetString(strings.TrimSpace(s), 0)
+	if !ok || v.Sign() < 0 || v.BitLen() > 256 {
+		return common.Hash{}, fmt.Errorf("invalid number %q", s)
+	}
+	return common.BigToHash(v), nil
+}
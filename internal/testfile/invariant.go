@@ -0,0 +1,82 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package testfile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/loader"
+)
+
+// CompileInvariant compiles the invariant check contract of the test file. It returns
+// nil if the file has no invariant. The fork is used as the default instruction set.
+func (f *File) CompileInvariant(fsys fs.FS, fork string) ([]byte, error) {
+	if f.Invariant == "" {
+		return nil, nil
+	}
+	path, err := loader.ResolveRelative(f.Path, f.Invariant)
+	if err != nil {
+		return nil, err
+	}
+	return CompileInvariant(fsys, path, fork)
+}
+
+// CompileInvariant compiles an invariant check contract.
+func CompileInvariant(fsys fs.FS, path, fork string) ([]byte, error) {
+	c := asm.New(fsys)
+	c.SetDefaultFork(fork)
+	code := c.CompileFile(path)
+	if c.Failed() {
+		return nil, fmt.Errorf("invariant: %w", errors.Join(c.Errors()...))
+	}
+	return code, nil
+}
+
+// CheckInvariant runs an invariant check contract against the given state. The check
+// contract executes in place of the contract under test, i.e. at evmrun.DefaultAddress,
+// so it can read the contract storage using SLOAD. The invariant holds when the check
+// contract does not fail. The state is not modified.
+func CheckInvariant(code []byte, fork string, st *state.StateDB) error {
+	res, err := evmrun.Run(code, evmrun.Config{Fork: fork, State: st.Copy()})
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.Reverted() && res.RevertReason != "":
+		return fmt.Errorf("invariant violated: %s", res.RevertReason)
+	case res.Err != nil:
+		return fmt.Errorf("invariant violated: %v", res.Err)
+	}
+	return nil
+}
+
+// CheckInvariant runs the invariant check contract against the post-state of a test
+// case, and records a failure if it does not hold. Nothing is checked if code is nil
+// or the test case failed to execute.
+func (r *Result) CheckInvariant(code []byte, fork string) {
+	if code == nil || r.Exec.Err != nil {
+		return
+	}
+	if err := CheckInvariant(code, fork, r.Exec.State); err != nil {
+		r.Failures = append(r.Failures, err.Error())
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package testfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Snapshot records the gas usage of test cases. In text form, it has one line per test
+// case, in the format:
+//
+//	<test file>:<case name> (gas: <gas used>)
+type Snapshot map[string]uint64
+
+// SnapshotKey returns the snapshot entry name of a test case.
+func SnapshotKey(file string, tc *Case) string {
+	return file + ":" + tc.Name
+}
+
+// ReadSnapshot parses a snapshot file.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	snap := make(Snapshot)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		name, gas, ok := strings.Cut(text, " (gas: ")
+		if !ok || !strings.HasSuffix(gas, ")") {
+			return nil, fmt.Errorf("line %d: invalid snapshot entry", line)
+		}
+		v, err := strconv.ParseUint(strings.TrimSuffix(gas, ")"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid gas value", line)
+		}
+		snap[name] = v
+	}
+	return snap, scanner.Err()
+}
+
+// WriteTo writes the snapshot in text form. Entries are sorted by name.
+func (s Snapshot) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, name := range slices.Sorted(maps.Keys(s)) {
+		n, err := fmt.Fprintf(w, "%s (gas: %d)\n", name, s[name])
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// SnapshotChange is a difference between snapshots.
+type SnapshotChange struct {
+	Name     string
+	Old, New uint64
+	Added    bool // entry does not exist in old snapshot
+	Removed  bool // entry does not exist in new snapshot
+}
+
+// Percent returns the relative change of gas usage.
+func (c SnapshotChange) Percent() float64 {
+	if c.Old == 0 {
+		return 0
+	}
+	return (float64(c.New) - float64(c.Old)) / float64(c.Old) * 100
+}
+
+// Compare returns the changes from old to s, sorted by name.
+func (s Snapshot) Compare(old Snapshot) []SnapshotChange {
+	var changes []SnapshotChange
+	for _, name := range slices.Sorted(maps.Keys(s)) {
+		oldGas, ok := old[name]
+		switch {
+		case !ok:
+			changes = append(changes, SnapshotChange{Name: name, New: s[name], Added: true})
+		case oldGas != s[name]:
+			changes = append(changes, SnapshotChange{Name: name, Old: oldGas, New: s[name]})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := s[name]; !ok {
+			changes = append(changes, SnapshotChange{Name: name, Old: old[name], Removed: true})
+		}
+	}
+	slices.SortFunc(changes, func(a, b SnapshotChange) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return changes
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package testfile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundtrip(t *testing.T) {
+	snap := Snapshot{"b_test.yaml:x": 100, "a_test.yaml:y": 21000}
+	var buf bytes.Buffer
+	if _, err := snap.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "a_test.yaml:y (gas: 21000)\nb_test.yaml:x (gas: 100)\n"
+	if buf.String() != want {
+		t.Fatalf("wrong snapshot text:\n%s", buf.String())
+	}
+	parsed, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, snap) {
+		t.Errorf("wrong parsed snapshot %v", parsed)
+	}
+
+	if _, err := ReadSnapshot(strings.NewReader("a_test.yaml:y (gas: x)\n")); err == nil {
+		t.Error("expected error for invalid gas value")
+	}
+}
+
+func TestSnapshotCompare(t *testing.T) {
+	old := Snapshot{"a": 100, "b": 200, "c": 300}
+	snap := Snapshot{"a": 110, "b": 200, "d": 50}
+	want := []SnapshotChange{
+		{Name: "a", Old: 100, New: 110},
+		{Name: "c", Old: 300, Removed: true},
+		{Name: "d", New: 50, Added: true},
+	}
+	changes := snap.Compare(old)
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("wrong changes %+v", changes)
+	}
+	if p := changes[0].Percent(); p != 10 {
+		t.Errorf("wrong percentage %v", p)
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package testfile implements contract test files.
+//
+// A test file is a YAML document which names the contract source file, and declares
+// test cases. Each test case is a call into the contract, along with the expected
+// outcome:
+//
+//	contract: token.eas
+//	target: cancun
+//	tests:
+//	  transfer:
+//	    input: 0xa9059cbb...
+//	    storage: {0x01: 100}
+//	    expect:
+//	      return: 0x01
+//	      storage: {0x01: 90}
+//	      gas-max: 30000
+//
+// The optional invariant is a check contract which runs against the post-state of every
+// successful test case. See [CheckInvariant].
+package testfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/fjl/geas/asm"
+	"github.com/fjl/geas/internal/evmrun"
+	"github.com/fjl/geas/internal/loader"
+	"gopkg.in/yaml.v3"
+)
+
+// Suffix is the file name suffix of test files.
+const Suffix = "_test.yaml"
+
+// File is a test file.
+type File struct {
+	Path      string  // location of the test file
+	Contract  string  // contract source file, relative to the test file
+	Target    string  // default instruction set of the contract
+	Invariant string  // invariant check contract, relative to the test file
+	Cases     []*Case // in order of appearance
+}
+
+// Case is a test case.
+type Case struct {
+	Name    string `yaml:"-"`
+	Line    int    `yaml:"-"` // line number in test file
+	Comment string `yaml:"-"` // comment above the test case
+	Call    `yaml:",inline"`
+	Expect  Expect `yaml:"expect,omitempty"`
+}
+
+// Expect contains the expected outcome of a test case.
+// Fields that are not set are not checked.
+type Expect struct {
+	Return  *string           `yaml:"return,omitempty"`  // return data as hex
+	Revert  bool              `yaml:"revert,omitempty"`  // whether execution reverts
+	Reason  *string           `yaml:"reason,omitempty"`  // revert reason
+	Storage map[string]string `yaml:"storage,omitempty"` // storage after execution
+	Logs    *[]Log            `yaml:"logs,omitempty"`    // emitted logs
+	GasMin  uint64            `yaml:"gas-min,omitempty"` // lower bound of gas used
+	GasMax  uint64            `yaml:"gas-max,omitempty"` // upper bound of gas used
+}
+
+// Log is an expected log entry.
+type Log struct {
+	Topics []string `yaml:"topics,omitempty"`
+	Data   string   `yaml:"data,omitempty"`
+}
+
+type fileYAML struct {
+	Contract  string          `yaml:"contract"`
+	Target    string          `yaml:"target,omitempty"`
+	Invariant string          `yaml:"invariant,omitempty"`
+	Tests     map[string]Case `yaml:"tests"`
+}
+
+// Load reads a test file.
+func Load(fsys fs.FS, path string) (*File, error) {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	var doc fileYAML
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if doc.Contract == "" {
+		return nil, fmt.Errorf("%s: missing contract", path)
+	}
+	f := &File{Path: path, Contract: doc.Contract, Target: doc.Target, Invariant: doc.Invariant}
+
+	// Decode again as a node tree to get the order and location of tests.
+	var root struct {
+		Tests yaml.Node `yaml:"tests"`
+	}
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i := 0; i+1 < len(root.Tests.Content); i += 2 {
+		key := root.Tests.Content[i]
+		tc := doc.Tests[key.Value]
+		tc.Name = key.Value
+		tc.Line = key.Line
+		tc.Comment = parseComment(key.HeadComment)
+		f.Cases = append(f.Cases, &tc)
+	}
+	return f, nil
+}
+
+// parseComment strips the comment markers of a YAML comment.
+func parseComment(c string) string {
+	var lines []string
+	for _, line := range strings.Split(c, "\n") {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Encode writes the test file as YAML. Test cases are written in order, with their
+// comment above them.
+func (f *File) Encode(w io.Writer) error {
+	head := &yaml.Node{Kind: yaml.MappingNode}
+	addField := func(key string, value *yaml.Node) {
+		head.Content = append(head.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	addField("contract", &yaml.Node{Kind: yaml.ScalarNode, Value: f.Contract})
+	if f.Target != "" {
+		addField("target", &yaml.Node{Kind: yaml.ScalarNode, Value: f.Target})
+	}
+	if f.Invariant != "" {
+		addField("invariant", &yaml.Node{Kind: yaml.ScalarNode, Value: f.Invariant})
+	}
+	tests := &yaml.Node{Kind: yaml.MappingNode}
+	for _, tc := range f.Cases {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: tc.Name}
+		if tc.Comment != "" {
+			key.HeadComment = "# " + strings.ReplaceAll(tc.Comment, "\n", "\n# ")
+		}
+		value := new(yaml.Node)
+		if err := value.Encode(tc); err != nil {
+			return err
+		}
+		tests.Content = append(tests.Content, key, value)
+	}
+	addField("tests", tests)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(head); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Result is the outcome of a test case.
+type Result struct {
+	Case     *Case
+	Failures []string
+	Exec     *evmrun.Result
+
+	// End is the instruction where execution of the contract ended.
+	// This can be nil when execution ended outside of the program.
+	End *asm.DebugInstruction
+}
+
+// Passed reports whether the test has passed.
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Compile compiles the contract of the test file.
+func (f *File) Compile(fsys fs.FS) ([]byte, *asm.DebugInfo, error) {
+	path, err := loader.ResolveRelative(f.Path, f.Contract)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := asm.New(fsys)
+	if f.Target != "" {
+		c.SetDefaultFork(f.Target)
+	}
+	code := c.CompileFile(path)
+	if c.Failed() {
+		return nil, nil, errors.Join(c.Errors()...)
+	}
+	return code, c.DebugInfo(), nil
+}
+
+// Run compiles the contract and executes all test cases.
+func (f *File) Run(fsys fs.FS) ([]*Result, error) {
+	code, info, err := f.Compile(fsys)
+	if err != nil {
+		return nil, err
+	}
+	invariant, err := f.CompileInvariant(fsys, info.Fork)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*Result, len(f.Cases))
+	for i, tc := range f.Cases {
+		if results[i], err = tc.Run(code, info, nil); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", f.Path, tc.Line, tc.Name, err)
+		}
+		results[i].CheckInvariant(invariant, info.Fork)
+	}
+	return results, nil
+}
+
+// Run executes the test case. The tracer is optional.
+func (tc *Case) Run(code []byte, info *asm.DebugInfo, tracer *tracing.Hooks) (*Result, error) {
+	cfg, err := tc.Config(info.Fork)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Tracer = tracer
+	exec, err := evmrun.Run(code, cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := &Result{Case: tc, Exec: exec, End: info.InstructionAt(int(exec.LastPC))}
+	if err := tc.Expect.check(r, evmrun.DefaultAddress); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (e *Expect) check(r *Result, addr common.Address) error {
+	exec := r.Exec
+	fail := func(format string, args ...any) {
+		r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+	}
+
+	// Check execution status.
+	switch {
+	case e.Revert && !exec.Reverted():
+		if exec.Err != nil {
+			fail("expected revert, got error: %v", exec.Err)
+		} else {
+			fail("expected revert")
+		}
+	case !e.Revert && exec.Reverted():
+		if exec.RevertReason != "" {
+			fail("unexpected revert: %s", exec.RevertReason)
+		} else {
+			fail("unexpected revert")
+		}
+	case !exec.Reverted() && exec.Err != nil:
+		fail("execution error: %v", exec.Err)
+	}
+	if e.Reason != nil && exec.RevertReason != *e.Reason {
+		fail("revert reason %q, want %q", exec.RevertReason, *e.Reason)
+	}
+
+	// Check return data.
+	if e.Return != nil {
+		want, err := ParseHex(*e.Return)
+		if err != nil {
+			return fmt.Errorf("invalid expected return: %v", err)
+		}
+		if !bytes.Equal(exec.ReturnData, want) {
+			fail("return data 0x%x, want 0x%x", exec.ReturnData, want)
+		}
+	}
+
+	// Check storage.
+	if len(e.Storage) > 0 {
+		storage, err := ParseStorage(e.Storage)
+		if err != nil {
+			return fmt.Errorf("invalid expected %v", err)
+		}
+		for slot, want := range storage {
+			if have := exec.State.GetState(addr, slot); have != want {
+				fail("storage slot %v is %v, want %v", slot, have, want)
+			}
+		}
+	}
+
+	// Check logs.
+	if e.Logs != nil {
+		if err := checkLogs(*e.Logs, exec, fail); err != nil {
+			return err
+		}
+	}
+
+	// Check gas.
+	if e.GasMax > 0 && exec.GasUsed > e.GasMax {
+		fail("gas used %d exceeds maximum %d", exec.GasUsed, e.GasMax)
+	}
+	if exec.GasUsed < e.GasMin {
+		fail("gas used %d is below minimum %d", exec.GasUsed, e.GasMin)
+	}
+	return nil
+}
+
+func checkLogs(want []Log, exec *evmrun.Result, fail func(string, ...any)) error {
+	if len(exec.Logs) != len(want) {
+		fail("%d logs emitted, want %d", len(exec.Logs), len(want))
+		return nil
+	}
+	for i, wl := range want {
+		have := exec.Logs[i]
+		topics := make([]common.Hash, len(wl.Topics))
+		for j, t := range wl.Topics {
+			var err error
+			if topics[j], err = ParseWord(t); err != nil {
+				return fmt.Errorf("invalid expected topic in log %d: %v", i, err)
+			}
+		}
+		data, err := ParseHex(wl.Data)
+		if err != nil {
+			return fmt.Errorf("invalid expected data in log %d: %v", i, err)
+		}
+		if !equalTopics(have.Topics, topics) {
+			fail("log %d has topics %s, want %s", i, formatTopics(have.Topics), formatTopics(topics))
+		}
+		if !bytes.Equal(have.Data, data) {
+			fail("log %d has data 0x%x, want 0x%x", i, have.Data, data)
+		}
+	}
+	return nil
+}
+
+func equalTopics(a, b []common.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatTopics(topics []common.Hash) string {
+	s := make([]string, len(topics))
+	for i, t := range topics {
+		s[i] = t.Hex()
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package testfile
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"contracts/counter.eas": {Data: []byte(`
+		push 0
+		sload               ; [count]
+		push 1
+		add                 ; [count+1]
+		dup1                ; [count+1, count+1]
+		push 0
+		sstore              ; [count+1]
+		dup1                ; [count+1, count+1]
+		push 0
+		mstore              ; [count+1]
+		push 5
+		eq                  ; [count+1 == 5]
+		jumpi @fail         ; []
+		push 32
+		push 0
+		log0
+		push 32
+		push 0
+		return
+	fail:
+		push 0
+		push 0
+		revert
+	`)},
+	"contracts/counter_test.yaml": {Data: []byte(`
+contract: counter.eas
+target: cancun
+tests:
+  first:
+    expect:
+      return: 0x0000000000000000000000000000000000000000000000000000000000000001
+      storage: {0: 1}
+      logs:
+        - data: 0x0000000000000000000000000000000000000000000000000000000000000001
+  limit:
+    storage: {0: 4}
+    expect:
+      revert: true
+  wrong:
+    storage: {0x00: 1}
+    expect:
+      return: 0x01
+      storage: {0: 1}
+      logs: []
+      gas-max: 100
+`)},
+	"contracts/below3.eas": {Data: []byte(`
+		push 0
+		sload               ; [count]
+		push 3
+		gt                  ; [3 > count]
+		jumpi @ok           ; []
+		push 0
+		push 0
+		revert
+	ok:
+	`)},
+	"contracts/invariant_test.yaml": {Data: []byte(`
+contract: counter.eas
+invariant: below3.eas
+tests:
+  ok:
+    storage: {0: 1}
+  broken:
+    storage: {0: 2}
+`)},
+	"contracts/invalid_test.yaml": {Data: []byte(`
+contract: counter.eas
+tests:
+  a:
+    inputs: 0x01
+`)},
+}
+
+func TestLoad(t *testing.T) {
+	f, err := Load(testFS, "contracts/counter_test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var lines []int
+	for _, tc := range f.Cases {
+		names = append(names, tc.Name)
+		lines = append(lines, tc.Line)
+	}
+	if !slices.Equal(names, []string{"first", "limit", "wrong"}) {
+		t.Errorf("wrong case names %q", names)
+	}
+	if !slices.Equal(lines, []int{5, 11, 15}) {
+		t.Errorf("wrong case lines %v", lines)
+	}
+
+	if _, err := Load(testFS, "contracts/invalid_test.yaml"); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestRun(t *testing.T) {
+	f, err := Load(testFS, "contracts/counter_test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := f.Run(testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Passed() {
+		t.Errorf("test %q failed: %q", results[0].Case.Name, results[0].Failures)
+	}
+	if !results[1].Passed() {
+		t.Errorf("test %q failed: %q", results[1].Case.Name, results[1].Failures)
+	}
+
+	r := results[2]
+	want := []string{
+		"return data 0x0000000000000000000000000000000000000000000000000000000000000002, want 0x01",
+		"storage slot 0x0000000000000000000000000000000000000000000000000000000000000000 is 0x0000000000000000000000000000000000000000000000000000000000000002, want 0x0000000000000000000000000000000000000000000000000000000000000001",
+		"1 logs emitted, want 0",
+	}
+	if !slices.Equal(r.Failures[:3], want) {
+		t.Errorf("wrong failures %q", r.Failures)
+	}
+	if len(r.Failures) != 4 {
+		t.Errorf("expected gas failure, got %q", r.Failures)
+	}
+	if r.End == nil || r.End.Op != "RETURN" || r.End.Pos.Line != 20 {
+		t.Errorf("wrong end instruction %+v", r.End)
+	}
+}
+
+func TestInvariant(t *testing.T) {
+	f, err := Load(testFS, "contracts/invariant_test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := f.Run(testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Passed() {
+		t.Errorf("test %q failed: %q", results[0].Case.Name, results[0].Failures)
+	}
+	if want := []string{"invariant violated: execution reverted"}; !slices.Equal(results[1].Failures, want) {
+		t.Errorf("wrong failures %q", results[1].Failures)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	ret := "0x01"
+	f := &File{
+		Path:      "contracts/gen_test.yaml",
+		Contract:  "counter.eas",
+		Invariant: "below3.eas",
+		Cases: []*Case{
+			{Name: "b", Comment: "first line\nsecond line", Call: Call{Input: "0x01", Storage: map[string]string{"0x00": "0x01"}}},
+			{Name: "a", Expect: Expect{Return: &ret}},
+		},
+	}
+	var buf strings.Builder
+	if err := f.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `contract: counter.eas
+invariant: below3.eas
+tests:
+  # first line
+  # second line
+  b:
+    input: "0x01"
+    storage:
+      "0x00": "0x01"
+  a:
+    expect:
+      return: "0x01"
+`
+	if buf.String() != want {
+		t.Fatalf("wrong output:\n%s", buf.String())
+	}
+
+	fsys := fstest.MapFS{"contracts/gen_test.yaml": {Data: []byte(buf.String())}}
+	loaded, err := Load(fsys, "contracts/gen_test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Invariant != f.Invariant || len(loaded.Cases) != 2 {
+		t.Fatalf("wrong file %+v", loaded)
+	}
+	if c := loaded.Cases[0]; c.Name != "b" || c.Comment != f.Cases[0].Comment || c.Input != "0x01" {
+		t.Errorf("wrong case %+v", c)
+	}
+}